@@ -0,0 +1,148 @@
+/*
+Bounded least recently used cache. Lookups, insertions and evictions are O(1): entries live in a map for
+lookups and in a doubly linked list ordered by recency for evictions.
+*/
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+/*
+Counters describing how well the cache is doing.
+*/
+type Stats struct {
+	Hits      uint64 // Get calls that found the key.
+	Misses    uint64 // Get calls that did not find the key.
+	Evictions uint64 // Entries removed to make room for new ones.
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+/*
+LRU cache holding at most Capacity entries. Safe for concurrent use.
+*/
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[K]*list.Element
+	order    *list.List // Front is the most recently used entry.
+	stats    Stats
+}
+
+/*
+Creates a cache holding at most capacity entries. A capacity below 1 is treated as 1.
+*/
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRU[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+/*
+Returns the value stored for key and marks it as most recently used.
+*/
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*entry[K, V]).value, true
+}
+
+/*
+Stores value for key and marks it as most recently used, evicting the least recently used entry if the
+cache is full.
+*/
+func (c *LRU[K, V]) Put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*entry[K, V]).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry[K, V]).key)
+		c.stats.Evictions++
+	}
+}
+
+/*
+Removes key from the cache. It does not count as an eviction.
+*/
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+/*
+Removes every entry for which remove returns true, without affecting recency.
+*/
+func (c *LRU[K, V]) DeleteFunc(remove func(key K, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		e := elem.Value.(*entry[K, V])
+		if remove(e.key, e.value) {
+			c.order.Remove(elem)
+			delete(c.items, e.key)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+/*
+Calls f for every entry, from most to least recently used, without affecting recency. f must not call
+back into the cache.
+*/
+func (c *LRU[K, V]) Range(f func(key K, value V)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry[K, V])
+		f(e.key, e.value)
+	}
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) Capacity() int {
+	return c.capacity
+}
+
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
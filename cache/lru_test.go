@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// Returns the keys of c from most to least recently used.
+func keys(c *LRU[string, int]) []string {
+	var keys []string
+	c.Range(func(key string, _ int) { keys = append(keys, key) })
+	return keys
+}
+
+func TestRangeOrder(t *testing.T) {
+	c := NewLRU[string, int](4)
+	for i, key := range []string{"a", "b", "c"} {
+		c.Put(key, i)
+	}
+	if got, want := keys(c), []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Range visited %v, want %v", got, want)
+	}
+	// Range and Len do not count as uses.
+	c.Len()
+	if got, want := keys(c), []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range visited %v after a Range, want %v", got, want)
+	}
+}
+
+func TestGetRefreshesRecency(t *testing.T) {
+	c := NewLRU[string, int](3)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %t, want 1, true", v, ok)
+	}
+	if got, want := keys(c), []string{"a", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range visited %v, want %v", got, want)
+	}
+	// b is now the least recently used entry, so it makes room for d.
+	c.Put("d", 4)
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("b was not evicted")
+	}
+	if got, want := keys(c), []string{"d", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range visited %v, want %v", got, want)
+	}
+}
+
+func TestPutExisting(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("a", 10)
+	if v, _ := c.Get("a"); v != 10 {
+		t.Errorf("Get(a) = %d, want 10", v)
+	}
+	// Replacing a value refreshes it and evicts nothing.
+	c.Put("c", 3)
+	if got, want := keys(c), []string{"c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range visited %v, want %v", got, want)
+	}
+	if stats := c.Stats(); stats.Evictions != 1 {
+		t.Errorf("%d evictions, want 1", stats.Evictions)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	c := NewLRU[string, int](3)
+	for i := 0; i < 10; i++ {
+		c.Put(fmt.Sprint(i), i)
+		if c.Len() > c.Capacity() {
+			t.Fatalf("%d entries after %d puts, more than the capacity of %d", c.Len(), i+1, c.Capacity())
+		}
+	}
+	if got, want := keys(c), []string{"9", "8", "7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range visited %v, want %v", got, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Get("a")
+	c.Get("a")
+	c.Get("x")
+	c.Put("c", 3) // Evicts b
+	c.Put("d", 4) // Evicts a
+	c.Get("b")
+	c.Delete("c")
+	c.DeleteFunc(func(string, int) bool { return true })
+	want := Stats{Hits: 2, Misses: 2, Evictions: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if c.Len() != 0 {
+		t.Errorf("%d entries left after deleting every one", c.Len())
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c := NewLRU[string, int](4)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		c.Put(key, i)
+	}
+	c.Delete("b")
+	c.Delete("x")
+	if n := c.DeleteFunc(func(_ string, v int) bool { return v%2 == 0 }); n != 2 {
+		t.Errorf("DeleteFunc removed %d entries, want 2", n)
+	}
+	if got, want := keys(c), []string{"d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range visited %v, want %v", got, want)
+	}
+}
+
+func TestCapacityBelowOne(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		c := NewLRU[string, int](capacity)
+		if c.Capacity() != 1 {
+			t.Errorf("NewLRU(%d) has a capacity of %d, want 1", capacity, c.Capacity())
+		}
+		c.Put("a", 1)
+		c.Put("b", 2)
+		if got, want := keys(c), []string{"b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("NewLRU(%d) holds %v, want %v", capacity, got, want)
+		}
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	c := NewLRU[string, int](8)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprint(i % 16)
+				c.Put(key, i)
+				c.Get(key)
+				c.Range(func(string, int) {})
+				if i%100 == 0 {
+					c.DeleteFunc(func(_ string, v int) bool { return v%2 == 0 })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Len() > c.Capacity() {
+		t.Errorf("%d entries, more than the capacity of %d", c.Len(), c.Capacity())
+	}
+	if stats := c.Stats(); stats.Hits+stats.Misses != 4000 {
+		t.Errorf("%d hits and %d misses, want 4000 Get calls", stats.Hits, stats.Misses)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Runs random scenarios on the deterministic simulator (see the sim package): a ring is built and filled with
+keys, then goes through rounds of crashes, joins, leaves, partitions, delays and lossy links. After each round
+the faults are healed and the ring must settle, with every key still retrievable.
+
+	go run ./cmd/sim -seeds 20 -faults crash,leave,join
+
+A failing run prints its seed and the violated invariants, and is replayed exactly with -seed. It exits with
+status 1 if any seed fails.
+
+A partition, or delays past the RPC timeout, split the ring into one ring per side. The nodes keep trying the
+successors they lost, and the rings merge back once the network heals, provided it heals within
+node.SKIPPED_RETRY_PERIOD (5 minutes, i.e. 300 steps), far longer than -fault-steps.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/node"
+	"github.com/fauzxan/dns-chord/v2/sim"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Kinds of faults, in the order they are drawn.
+var faultKinds = []string{"crash", "leave", "join", "partition", "delay", "loss", "rewrite"}
+
+type scenario struct {
+	nodes, keys, rounds, settle, quiet, faultSteps, idBits, successors int
+	readRepair                                                         bool
+	faults                                                             []string
+	verbose                                                            bool
+}
+
+func main() {
+	seed := flag.Int64("seed", 1, "seed of the first run")
+	seeds := flag.Int("seeds", 1, "runs, with consecutive seeds")
+	nodes := flag.Int("nodes", 8, "nodes in the initial ring")
+	keys := flag.Int("keys", 100, "keys put into the ring")
+	rounds := flag.Int("rounds", 10, "rounds of faults in each run")
+	settle := flag.Int("settle", 120, "steps the ring has to settle after each round")
+	quiet := flag.Int("quiet", 5, "steps between rounds, once the ring has settled")
+	faultSteps := flag.Int("fault-steps", 10, "steps a partition, delay or lossy link lasts")
+	idBits := flag.Int("id-bits", node.DEFAULT_ID_BITS, "width of node IDs and keys")
+	successors := flag.Int("successors", node.DEFAULT_SUCC_LIST_LENGTH, "length of the successor list of the nodes")
+	readRepair := flag.Bool("read-repair", false, "reads consult every replica and repair the stale ones")
+	faults := flag.String("faults", strings.Join(faultKinds, ","), "comma separated kinds of faults to inject")
+	logLevel := flag.String("log-level", "disabled", "log level of the nodes")
+	verbose := flag.Bool("v", false, "print each round")
+	flag.Parse()
+
+	level, err := zerolog.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	zerolog.SetGlobalLevel(level)
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	sc := scenario{nodes: *nodes, keys: *keys, rounds: *rounds, settle: *settle, quiet: *quiet, faultSteps: *faultSteps, idBits: *idBits, successors: *successors, readRepair: *readRepair, verbose: *verbose}
+	for _, kind := range strings.Split(*faults, ",") {
+		known := false
+		for _, k := range faultKinds {
+			known = known || k == kind
+		}
+		if !known {
+			fmt.Fprintf(os.Stderr, "unknown fault %q, want some of %s\n", kind, strings.Join(faultKinds, ","))
+			os.Exit(2)
+		}
+		sc.faults = append(sc.faults, kind)
+	}
+	failed := 0
+	for s := *seed; s < *seed+int64(*seeds); s++ {
+		start := time.Now()
+		if err := sc.run(s); err != nil {
+			fmt.Printf("FAIL %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("ok   seed %d (%s)\n", s, time.Since(start).Round(time.Millisecond))
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d seeds failed\n", failed, *seeds)
+		os.Exit(1)
+	}
+}
+
+func (sc scenario) run(seed int64) error {
+	s, err := sim.New(sim.Config{Nodes: sc.nodes, Seed: seed, IDBits: sc.idBits, SuccListLength: sc.successors, ReadRepair: sc.readRepair})
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if err := s.Settle(sc.settle); err != nil {
+		return fmt.Errorf("initial ring: %w", err)
+	}
+	if err := s.PutKeys(sc.keys); err != nil {
+		return fmt.Errorf("seed %d: %w", seed, err)
+	}
+	// Keys are replicated by a periodic task, and are lost if their owner crashes before it runs
+	s.Run(sc.quiet)
+	// Separate from the simulation's, so that the scenario does not change when the simulation draws more
+	r := rand.New(rand.NewSource(seed))
+	for round := 1; round <= sc.rounds; round++ {
+		action := sc.fault(s, r)
+		if sc.verbose {
+			fmt.Printf("seed %d round %d step %d: %s\n", seed, round, s.Steps, action)
+		}
+		s.Heal()
+		if err := s.Settle(sc.settle); err != nil {
+			return fmt.Errorf("round %d (%s): %w", round, action, err)
+		}
+		s.Run(sc.quiet)
+	}
+	return nil
+}
+
+/*
+Applies a random fault to the ring, and runs the steps it lasts. Crashes and leaves keep at least two nodes
+alive, and only one node fails at a time, which the replicas on the successors survive.
+*/
+func (sc scenario) fault(s *sim.Sim, r *rand.Rand) string {
+	alive := s.Alive()
+	pick := func() int { return alive[r.Intn(len(alive))] }
+	switch sc.faults[r.Intn(len(sc.faults))] {
+	case "crash":
+		if len(alive) > 2 {
+			i := pick()
+			s.Kill(i)
+			return fmt.Sprintf("crash node %d", i)
+		}
+	case "leave":
+		if len(alive) > 2 {
+			i := pick()
+			if err := s.Leave(i); err != nil {
+				return fmt.Sprintf("node %d left: %v", i, err)
+			}
+			return fmt.Sprintf("node %d left", i)
+		}
+	case "join":
+		i, err := s.AddNode()
+		if err != nil {
+			return fmt.Sprintf("node %d could not join: %v", i, err)
+		}
+		return fmt.Sprintf("node %d joined", i)
+	case "partition":
+		perm := r.Perm(len(alive))
+		var a, b []int
+		for k, p := range perm {
+			if k < len(perm)/2 {
+				a = append(a, alive[p])
+			} else {
+				b = append(b, alive[p])
+			}
+		}
+		s.Partition(a, b)
+		s.Run(sc.faultSteps)
+		return fmt.Sprintf("partition %v %v", a, b)
+	case "delay":
+		i, j := pick(), pick()
+		s.SetFault(i, j, sim.Fault{Delay: time.Duration(r.Intn(4000)) * time.Millisecond})
+		s.SetFault(j, i, sim.Fault{Delay: time.Duration(r.Intn(4000)) * time.Millisecond})
+		s.Run(sc.faultSteps)
+		return fmt.Sprintf("delay between nodes %d and %d", i, j)
+	case "loss":
+		for _, i := range alive {
+			for _, j := range alive {
+				s.SetFault(i, j, sim.Fault{DropRate: 0.1})
+			}
+		}
+		s.Run(sc.faultSteps)
+		return "lossy network"
+	case "rewrite":
+		name, err := s.Rewrite()
+		if err != nil {
+			return fmt.Sprintf("could not rewrite %s: %v", name, err)
+		}
+		s.Run(sc.faultSteps)
+		return fmt.Sprintf("rewrite %s", name)
+	}
+	return "nothing"
+}
@@ -0,0 +1,95 @@
+/*
+Runs the stress test of the stress package from the command line: a ring of nodes in one process, on loopback
+ports, hammered with joins, a leave, queries and menu-style reads while the periodic tasks run. go test -race
+./node runs it on the in-memory transport; this runs it over any transport, for longer, and prints the
+counters of the ring:
+
+	go run -race ./cmd/stress -nodes 8 -duration 30s
+
+The nodes talk over gRPC, over net/rpc with -transport tcp, or over the in-memory transport with
+-transport memory. -transport mixed runs every other node over net/rpc only, like a ring in the middle of an
+upgrade.
+
+The race detector reports data races on stderr and makes the program exit with status 66.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/node"
+	"github.com/fauzxan/dns-chord/v2/stress"
+	"github.com/fauzxan/dns-chord/v2/transport"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	nodes := flag.Int("nodes", 8, "nodes in the ring, the first one creates it and the others join while queries run")
+	duration := flag.Duration("duration", 20*time.Second, "how long to run the queries")
+	workers := flag.Int("workers", 8, "goroutines sending queries")
+	names := flag.Int("names", 200, "distinct websites queried")
+	basePort := flag.Int("base-port", 17000, "port of the stub upstream, the nodes listen on the next ones")
+	logLevel := flag.String("log-level", "disabled", "log level of the nodes")
+	transportName := flag.String("transport", "grpc", "transport between the nodes, grpc, tcp, mixed or memory")
+	vnodes := flag.Int("vnodes", 1, "virtual nodes hosted by each node")
+	readRepair := flag.Bool("read-repair", false, "reads consult every replica and repair the stale ones")
+	flag.Parse()
+
+	level, err := zerolog.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	zerolog.SetGlobalLevel(level)
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	var newTransport func(i int) transport.Transport
+	switch *transportName {
+	case "grpc":
+		newTransport = func(int) transport.Transport { return transport.NewGRPC(node.RPC_IDLE_TIMEOUT) }
+	case "tcp":
+		newTransport = func(int) transport.Transport { return transport.NewTCP(node.RPC_IDLE_TIMEOUT) }
+	case "mixed":
+		newTransport = func(i int) transport.Transport {
+			if i%2 == 1 {
+				return transport.NewTCP(node.RPC_IDLE_TIMEOUT)
+			}
+			return transport.NewGRPC(node.RPC_IDLE_TIMEOUT)
+		}
+	case "memory":
+		network := transport.NewMemoryNetwork()
+		newTransport = func(int) transport.Transport { return network.Transport() }
+	default:
+		fmt.Fprintf(os.Stderr, "unknown transport %s\n", *transportName)
+		os.Exit(2)
+	}
+
+	result, err := stress.Run(stress.Config{
+		Nodes:      *nodes,
+		VNodes:     *vnodes,
+		Duration:   *duration,
+		Workers:    *workers,
+		Names:      *names,
+		ReadRepair: *readRepair,
+		Upstream:   net.JoinHostPort("127.0.0.1", strconv.Itoa(*basePort)),
+		Transport:  newTransport,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if result.Nodes == 0 {
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("%d nodes, %d queries, %d failures\n", result.Nodes, result.Queries, result.Failures)
+	fmt.Printf("anti-entropy: %d rounds, %d in sync, %d bytes sent, %d saved\n", result.Sync.Rounds, result.Sync.InSync, result.Sync.BytesSent, result.Sync.BytesSaved())
+	fmt.Printf("read repair: %d divergent reads, %d replicas repaired\n", result.Repairs.Divergent, result.Repairs.Repairs)
+	fmt.Printf("hinted handoff: %d hints stored, %d delivered, %d expired, %d pending\n", result.Hints.Stored, result.Hints.Delivered, result.Hints.Expired, result.PendingHints)
+}
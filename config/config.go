@@ -0,0 +1,363 @@
+/*
+Settings of a node. They are read, in increasing order of precedence, from built-in defaults, a JSON config
+file, environment variables (including the .env file), and command-line flags.
+*/
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/mattn/go-isatty"
+	"github.com/rs/zerolog"
+)
+
+type Config struct {
+	Listen            string        // Address the chord RPC server binds to, e.g. ":3000".
+	Advertise         string        // Address other nodes use to reach this node. Derived from Listen if empty.
+	DNSListen         string        // Address the DNS server binds to. Disabled if empty.
+	Bootstrap         []string      // Nodes to contact to join a network, tried in order. A new network is created if empty.
+	DataDir           string        // Directory where the storage is persisted.
+	CacheSize         int           // Entries in the query cache.
+	ReplicationFactor int           // Number of successors that hold a replica of the node's keys.
+	Successors        int           // Length of the successor list, which bounds the successor failures survived.
+	ReadQuorum        int           // Replicas consulted by reads.
+	WriteQuorum       int           // Replicas that must acknowledge writes.
+	ReadRepair        bool          // Whether reads consult every replica, and repair the stale ones.
+	MaxHints          int           // Writes kept for replica holders that cannot be reached.
+	HintTTL           time.Duration // How long writes are kept for replica holders that cannot be reached.
+	IDBits            int           // Width of node IDs and keys. Every node of a network must use the same.
+	VirtualNodes      int           // Virtual nodes hosted per unit of weight. Every node of a network should use the same.
+	Weight            float64       // Capacity of the node relative to the others, which scales its number of virtual nodes.
+	LogLevel          string        // trace, debug, info, warn, error or disabled.
+	Upstreams         []string      // Upstream DNS servers. Read from /etc/resolv.conf if empty.
+	UpstreamTimeout   time.Duration // Timeout of each attempt to query an upstream server.
+	RPCTimeout        time.Duration // Deadline of each message sent to another node.
+	Interactive       bool          // Whether to prompt for missing settings and show the menu.
+
+	set map[string]bool // Settings that were configured explicitly, by flag name.
+}
+
+/*
+Returns the settings used when nothing else is configured. Interactive mode is only the default when
+stdin is a terminal.
+*/
+func Default() Config {
+	return Config{
+		Listen:            ":3000",
+		DNSListen:         ":5353",
+		DataDir:           "./data",
+		CacheSize:         1024,
+		ReplicationFactor: 2,
+		Successors:        4,
+		ReadQuorum:        2,
+		WriteQuorum:       2,
+		MaxHints:          1024,
+		HintTTL:           10 * time.Minute,
+		IDBits:            160,
+		VirtualNodes:      1,
+		Weight:            1,
+		LogLevel:          "info",
+		UpstreamTimeout:   2 * time.Second,
+		RPCTimeout:        3 * time.Second,
+		Interactive:       isatty.IsTerminal(os.Stdin.Fd()),
+	}
+}
+
+/*
+A setting that can be given as a flag, an environment variable, or a key of the config file. The key is
+the flag name.
+*/
+type setting struct {
+	flag   string
+	env    string
+	usage  string
+	isBool bool // Can be given as a bare flag, e.g. -interactive for -interactive=true.
+	set    func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{flag: "listen", env: "LISTEN", usage: "address the chord RPC server binds to", set: func(cfg *Config, v string) error {
+		cfg.Listen = v
+		return nil
+	}},
+	{flag: "advertise", env: "ADVERTISE", usage: "address other nodes use to reach this node", set: func(cfg *Config, v string) error {
+		cfg.Advertise = v
+		return nil
+	}},
+	{flag: "dns-listen", env: "DNS_LISTEN", usage: "address the DNS server binds to, empty to disable it", set: func(cfg *Config, v string) error {
+		cfg.DNSListen = v
+		return nil
+	}},
+	{flag: "bootstrap", env: "BOOTSTRAP", usage: "comma separated addresses of nodes to join, empty to create a network", set: func(cfg *Config, v string) error {
+		cfg.Bootstrap = splitList(v)
+		return nil
+	}},
+	{flag: "data-dir", env: "DATA_DIR", usage: "directory where the storage is persisted", set: func(cfg *Config, v string) error {
+		cfg.DataDir = v
+		return nil
+	}},
+	{flag: "cache-size", env: "CACHE_SIZE", usage: "entries in the query cache", set: func(cfg *Config, v string) error {
+		return setPositive(&cfg.CacheSize, v)
+	}},
+	{flag: "replication-factor", env: "REPLICATION_FACTOR", usage: "number of successors holding a replica", set: func(cfg *Config, v string) error {
+		return setPositive(&cfg.ReplicationFactor, v)
+	}},
+	{flag: "successors", env: "SUCCESSORS", usage: "length of the successor list, at least the replication factor", set: func(cfg *Config, v string) error {
+		return setPositive(&cfg.Successors, v)
+	}},
+	{flag: "read-quorum", env: "READ_QUORUM", usage: "replicas consulted by reads, out of replication factor + 1", set: func(cfg *Config, v string) error {
+		return setPositive(&cfg.ReadQuorum, v)
+	}},
+	{flag: "write-quorum", env: "WRITE_QUORUM", usage: "replicas that must acknowledge writes, out of replication factor + 1", set: func(cfg *Config, v string) error {
+		return setPositive(&cfg.WriteQuorum, v)
+	}},
+	{flag: "read-repair", env: "READ_REPAIR", usage: "consult every replica on reads rather than the read quorum, and repair the stale ones", isBool: true, set: func(cfg *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		cfg.ReadRepair = b
+		return nil
+	}},
+	{flag: "max-hints", env: "MAX_HINTS", usage: "writes kept for replica holders that cannot be reached, delivered when they are back", set: func(cfg *Config, v string) error {
+		return setPositive(&cfg.MaxHints, v)
+	}},
+	{flag: "hint-ttl", env: "HINT_TTL", usage: "how long writes are kept for replica holders that cannot be reached", set: func(cfg *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s is not positive", v)
+		}
+		cfg.HintTTL = d
+		return nil
+	}},
+	{flag: "id-bits", env: "ID_BITS", usage: fmt.Sprintf("width of node IDs and keys, up to %d bits, the same on every node", ring.MAX_BITS), set: func(cfg *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		if err := ring.CheckBits(n); err != nil {
+			return err
+		}
+		cfg.IDBits = n
+		return nil
+	}},
+	{flag: "vnodes", env: "VNODES", usage: "virtual nodes hosted per unit of weight, the same on every node", set: func(cfg *Config, v string) error {
+		return setPositive(&cfg.VirtualNodes, v)
+	}},
+	{flag: "weight", env: "WEIGHT", usage: "capacity relative to other nodes, which scales the number of virtual nodes", set: func(cfg *Config, v string) error {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		if !(w > 0) || math.IsInf(w, 1) {
+			return fmt.Errorf("%s is not a positive weight", v)
+		}
+		cfg.Weight = w
+		return nil
+	}},
+	{flag: "log-level", env: "LOG_LEVEL", usage: "trace, debug, info, warn, error or disabled", set: func(cfg *Config, v string) error {
+		if _, err := zerolog.ParseLevel(v); err != nil {
+			return err
+		}
+		cfg.LogLevel = v
+		return nil
+	}},
+	{flag: "upstreams", env: "UPSTREAMS", usage: "comma separated upstream DNS servers, read from /etc/resolv.conf if empty", set: func(cfg *Config, v string) error {
+		cfg.Upstreams = splitList(v)
+		return nil
+	}},
+	{flag: "upstream-timeout", env: "UPSTREAM_TIMEOUT", usage: "timeout of each attempt to query an upstream server", set: func(cfg *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		cfg.UpstreamTimeout = d
+		return nil
+	}},
+	{flag: "rpc-timeout", env: "RPC_TIMEOUT", usage: "deadline of each message sent to another node", set: func(cfg *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s is not positive", v)
+		}
+		cfg.RPCTimeout = d
+		return nil
+	}},
+	{flag: "interactive", env: "INTERACTIVE", usage: "prompt for missing settings and show the menu", isBool: true, set: func(cfg *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		cfg.Interactive = b
+		return nil
+	}},
+}
+
+/*
+Returns the number of virtual nodes to host: VirtualNodes scaled by Weight, and at least one.
+*/
+func (cfg Config) HostedNodes() int {
+	return max(1, int(math.Round(float64(cfg.VirtualNodes)*cfg.Weight)))
+}
+
+/*
+Builds the configuration from the command-line arguments (without the program name), the config file
+they point to with -config, and the environment.
+*/
+func Load(args []string) (Config, error) {
+	fs := flag.NewFlagSet("dns-chord", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("CONFIG"), "path to a JSON config file whose keys are the flag names")
+	flagValues := map[string]*flagValue{}
+	for _, s := range settings {
+		flagValues[s.flag] = &flagValue{isBool: s.isBool}
+		fs.Var(flagValues[s.flag], s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	cfg.set = map[string]bool{}
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return Config{}, err
+		}
+	}
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(&cfg, value); err != nil {
+				return Config{}, fmt.Errorf("env %s: %w", s.env, err)
+			}
+			cfg.set[s.flag] = true
+		}
+	}
+	// The DNS port used to be the only DNS setting, keep honouring it.
+	if port, ok := os.LookupEnv("DNS_PORT"); ok && os.Getenv("DNS_LISTEN") == "" {
+		cfg.DNSListen = ":" + port
+		cfg.set["dns-listen"] = true
+	}
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if err = s.set(&cfg, flagValues[s.flag].value); err != nil {
+					err = fmt.Errorf("flag -%s: %w", s.flag, err)
+				}
+				cfg.set[s.flag] = true
+			}
+		}
+	})
+	return cfg, err
+}
+
+/*
+Applies the settings of a JSON config file, e.g. {"listen": ":3000", "bootstrap": ["10.0.0.2:3000"]}.
+*/
+func (cfg *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var values map[string]any
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	for key, value := range values {
+		found := false
+		for _, s := range settings {
+			if s.flag != key {
+				continue
+			}
+			found = true
+			if err := s.set(cfg, fileValue(value)); err != nil {
+				return fmt.Errorf("config %s: %s: %w", path, key, err)
+			}
+			cfg.set[key] = true
+		}
+		if !found {
+			return fmt.Errorf("config %s: unknown setting %q", path, key)
+		}
+	}
+	return nil
+}
+
+/*
+Raw value of a flag, parsed by the setting it belongs to. Boolean flags can be given without a value.
+*/
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string { return f.value }
+
+func (f *flagValue) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+/*
+Returns true if the setting with the given flag name was configured explicitly rather than defaulted.
+*/
+func (cfg *Config) IsSet(name string) bool {
+	return cfg.set[name]
+}
+
+/*
+Returns the flag syntax of a JSON value. Lists become comma separated.
+*/
+func fileValue(value any) string {
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func setPositive(dst *int, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if n < 1 {
+		return fmt.Errorf("%d is not positive", n)
+	}
+	*dst = n
+	return nil
+}
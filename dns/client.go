@@ -0,0 +1,113 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	ErrIDMismatch       = errors.New("dns: response does not match the query")
+	ErrTruncatedOverTCP = errors.New("dns: response truncated over tcp")
+)
+
+/*
+Sends a query for (name, qtype) to server and returns its response. The query goes over UDP first, and
+is retried over TCP if the response is truncated. timeout bounds each of the two exchanges.
+*/
+func Exchange(server string, name string, qtype uint16, timeout time.Duration) (*Message, error) {
+	query := &Message{
+		Header:     Header{ID: uint16(rand.Uint32()), RecursionDesired: true},
+		Questions:  []Question{{Name: Fqdn(name), Type: qtype, Class: ClassINET}},
+		Additional: []RR{{Name: ".", Type: TypeOPT, Class: MaxUDPSize}},
+	}
+	buf, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := exchangeUDP(server, buf, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkResponse(query, resp); err != nil {
+		return nil, err
+	}
+	if !resp.Truncated {
+		return resp, nil
+	}
+	if resp, err = exchangeTCP(server, buf, timeout); err != nil {
+		return nil, err
+	}
+	if err = checkResponse(query, resp); err != nil {
+		return nil, err
+	}
+	if resp.Truncated {
+		return nil, ErrTruncatedOverTCP
+	}
+	return resp, nil
+}
+
+func exchangeUDP(server string, query []byte, timeout time.Duration) (*Message, error) {
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err = conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, MaxUDPSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := Unpack(buf[:n])
+		// Ignore garbage and stray responses to other queries, and keep waiting until the deadline.
+		if err != nil || resp.ID != binary.BigEndian.Uint16(query) {
+			continue
+		}
+		return resp, nil
+	}
+}
+
+func exchangeTCP(server string, query []byte, timeout time.Duration) (*Message, error) {
+	conn, err := net.DialTimeout("tcp", server, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	out := binary.BigEndian.AppendUint16(make([]byte, 0, len(query)+2), uint16(len(query)))
+	if _, err = conn.Write(append(out, query...)); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err = io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err = io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return Unpack(buf)
+}
+
+/*
+Makes sure resp answers query, to avoid accepting spoofed or misrouted responses.
+*/
+func checkResponse(query, resp *Message) error {
+	if !resp.Response || resp.ID != query.ID || len(resp.Questions) != 1 {
+		return ErrIDMismatch
+	}
+	q, r := query.Questions[0], resp.Questions[0]
+	if !strings.EqualFold(q.Name, r.Name) || q.Type != r.Type || q.Class != r.Class {
+		return ErrIDMismatch
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+/*
+Minimal implementation of the DNS wire format described in RFC 1035. It only covers what a chord node needs
+to act as a resolver for stub clients: parsing queries, and packing responses with name compression.
+*/
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Resource record types.
+const (
+	TypeA     uint16 = 1
+	TypeNS    uint16 = 2
+	TypeCNAME uint16 = 5
+	TypeSOA   uint16 = 6
+	TypePTR   uint16 = 12
+	TypeMX    uint16 = 15
+	TypeTXT   uint16 = 16
+	TypeAAAA  uint16 = 28
+	TypeSRV   uint16 = 33
+	TypeOPT   uint16 = 41
+	TypeANY   uint16 = 255
+)
+
+// Resource record classes.
+const (
+	ClassINET uint16 = 1
+	ClassANY  uint16 = 255
+)
+
+// Opcodes and response codes.
+const (
+	OpcodeQuery = 0
+
+	RcodeSuccess        = 0 // NOERROR
+	RcodeFormatError    = 1 // FORMERR
+	RcodeServerFailure  = 2 // SERVFAIL
+	RcodeNameError      = 3 // NXDOMAIN
+	RcodeNotImplemented = 4 // NOTIMP
+	RcodeRefused        = 5 // REFUSED
+)
+
+// Size limits.
+const (
+	headerLen        = 12
+	maxLabelLen      = 63
+	maxNameLen       = 255
+	MinUDPSize       = 512   // Largest UDP payload a client without EDNS0 accepts.
+	MaxUDPSize       = 4096  // Largest UDP payload we advertise or accept through EDNS0.
+	maxMessageLen    = 65535 // TCP messages carry a 2 byte length prefix.
+	maxCompressPtr   = 0x3FFF
+	compressionFlags = 0xC0
+)
+
+var (
+	ErrShortBuffer = errors.New("dns: message is too short")
+	ErrLabelLen    = errors.New("dns: label exceeds 63 octets")
+	ErrNameLen     = errors.New("dns: name exceeds 255 octets")
+	ErrPointer     = errors.New("dns: invalid compression pointer")
+	ErrRdata       = errors.New("dns: malformed rdata")
+	ErrMessageLen  = errors.New("dns: message exceeds 65535 octets")
+)
+
+type Header struct {
+	ID                 uint16
+	Response           bool
+	Opcode             int
+	Authoritative      bool
+	Truncated          bool
+	RecursionDesired   bool
+	RecursionAvailable bool
+	Rcode              int
+}
+
+type Question struct {
+	Name  string // Fully qualified, e.g. "example.com."
+	Type  uint16
+	Class uint16
+}
+
+/*
+A single resource record. Data holds the RDATA in presentation format, see rdata.go.
+*/
+type RR struct {
+	Name  string // Owner name, fully qualified.
+	Type  uint16
+	Class uint16
+	TTL   uint32 // In seconds.
+	Data  string // e.g. "192.0.2.1" for A, "10 mail.example.com." for MX.
+}
+
+/*
+Returns the record in zone file format.
+*/
+func (rr RR) String() string {
+	return fmt.Sprintf("%s\t%d\tIN\t%s\t%s", rr.Name, rr.TTL, TypeString(rr.Type), rr.Data)
+}
+
+type Message struct {
+	Header
+	Questions  []Question
+	Answers    []RR
+	Authority  []RR
+	Additional []RR
+}
+
+/*
+***************************************
+		PACKING
+***************************************
+*/
+
+/*
+Packs the message into wire format, compressing owner names.
+*/
+func (msg *Message) Pack() ([]byte, error) {
+	buf := make([]byte, headerLen, MinUDPSize)
+	binary.BigEndian.PutUint16(buf[0:], msg.ID)
+	binary.BigEndian.PutUint16(buf[2:], msg.flags())
+	binary.BigEndian.PutUint16(buf[4:], uint16(len(msg.Questions)))
+	binary.BigEndian.PutUint16(buf[6:], uint16(len(msg.Answers)))
+	binary.BigEndian.PutUint16(buf[8:], uint16(len(msg.Authority)))
+	binary.BigEndian.PutUint16(buf[10:], uint16(len(msg.Additional)))
+
+	compression := map[string]int{}
+	var err error
+	for _, q := range msg.Questions {
+		if buf, err = packName(buf, q.Name, compression); err != nil {
+			return nil, err
+		}
+		buf = binary.BigEndian.AppendUint16(buf, q.Type)
+		buf = binary.BigEndian.AppendUint16(buf, q.Class)
+	}
+	for _, section := range [][]RR{msg.Answers, msg.Authority, msg.Additional} {
+		for _, rr := range section {
+			if buf, err = packRR(buf, rr, compression); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(buf) > maxMessageLen {
+		return nil, ErrMessageLen
+	}
+	return buf, nil
+}
+
+func (h *Header) flags() uint16 {
+	var flags uint16
+	if h.Response {
+		flags |= 1 << 15
+	}
+	flags |= uint16(h.Opcode&0xF) << 11
+	if h.Authoritative {
+		flags |= 1 << 10
+	}
+	if h.Truncated {
+		flags |= 1 << 9
+	}
+	if h.RecursionDesired {
+		flags |= 1 << 8
+	}
+	if h.RecursionAvailable {
+		flags |= 1 << 7
+	}
+	flags |= uint16(h.Rcode & 0xF)
+	return flags
+}
+
+func packRR(buf []byte, rr RR, compression map[string]int) ([]byte, error) {
+	buf, err := packName(buf, rr.Name, compression)
+	if err != nil {
+		return nil, err
+	}
+	buf = binary.BigEndian.AppendUint16(buf, rr.Type)
+	buf = binary.BigEndian.AppendUint16(buf, rr.Class)
+	buf = binary.BigEndian.AppendUint32(buf, rr.TTL)
+	// RDLENGTH is only known once the RDATA is packed, so reserve it and fill it in afterwards.
+	lengthOff := len(buf)
+	buf = append(buf, 0, 0)
+	if buf, err = packRdata(buf, rr.Type, rr.Data, compression); err != nil {
+		return nil, err
+	}
+	rdlength := len(buf) - lengthOff - 2
+	if rdlength > 0xFFFF {
+		return nil, ErrRdata
+	}
+	binary.BigEndian.PutUint16(buf[lengthOff:], uint16(rdlength))
+	return buf, nil
+}
+
+/*
+Appends name to buf. Suffixes that were already written are replaced by a compression pointer.
+*/
+func packName(buf []byte, name string, compression map[string]int) ([]byte, error) {
+	name = Fqdn(name)
+	if len(name) > maxNameLen {
+		return nil, ErrNameLen
+	}
+	if name == "." {
+		return append(buf, 0), nil
+	}
+	for name != "" {
+		key := strings.ToLower(name)
+		if offset, ok := compression[key]; ok {
+			return binary.BigEndian.AppendUint16(buf, uint16(compressionFlags)<<8|uint16(offset)), nil
+		}
+		if compression != nil && len(buf) <= maxCompressPtr {
+			compression[key] = len(buf)
+		}
+		dot := strings.IndexByte(name, '.')
+		label := name[:dot]
+		if len(label) == 0 || len(label) > maxLabelLen {
+			return nil, ErrLabelLen
+		}
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+		name = name[dot+1:]
+	}
+	return append(buf, 0), nil
+}
+
+/*
+***************************************
+		UNPACKING
+***************************************
+*/
+
+/*
+Parses a wire format message.
+*/
+func Unpack(buf []byte) (*Message, error) {
+	if len(buf) < headerLen {
+		return nil, ErrShortBuffer
+	}
+	msg := &Message{}
+	msg.ID = binary.BigEndian.Uint16(buf[0:])
+	flags := binary.BigEndian.Uint16(buf[2:])
+	msg.Response = flags&(1<<15) != 0
+	msg.Opcode = int(flags>>11) & 0xF
+	msg.Authoritative = flags&(1<<10) != 0
+	msg.Truncated = flags&(1<<9) != 0
+	msg.RecursionDesired = flags&(1<<8) != 0
+	msg.RecursionAvailable = flags&(1<<7) != 0
+	msg.Rcode = int(flags & 0xF)
+	qdcount := int(binary.BigEndian.Uint16(buf[4:]))
+	ancount := int(binary.BigEndian.Uint16(buf[6:]))
+	nscount := int(binary.BigEndian.Uint16(buf[8:]))
+	arcount := int(binary.BigEndian.Uint16(buf[10:]))
+
+	off := headerLen
+	for i := 0; i < qdcount; i++ {
+		var q Question
+		var err error
+		if q.Name, off, err = unpackName(buf, off); err != nil {
+			return nil, err
+		}
+		if off+4 > len(buf) {
+			return nil, ErrShortBuffer
+		}
+		q.Type = binary.BigEndian.Uint16(buf[off:])
+		q.Class = binary.BigEndian.Uint16(buf[off+2:])
+		off += 4
+		msg.Questions = append(msg.Questions, q)
+	}
+	var err error
+	if msg.Answers, off, err = unpackSection(buf, off, ancount); err != nil {
+		return nil, err
+	}
+	if msg.Authority, off, err = unpackSection(buf, off, nscount); err != nil {
+		return nil, err
+	}
+	if msg.Additional, _, err = unpackSection(buf, off, arcount); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func unpackSection(buf []byte, off, count int) ([]RR, int, error) {
+	var rrs []RR
+	for i := 0; i < count; i++ {
+		var rr RR
+		var err error
+		if rr.Name, off, err = unpackName(buf, off); err != nil {
+			return nil, off, err
+		}
+		if off+10 > len(buf) {
+			return nil, off, ErrShortBuffer
+		}
+		rr.Type = binary.BigEndian.Uint16(buf[off:])
+		rr.Class = binary.BigEndian.Uint16(buf[off+2:])
+		rr.TTL = binary.BigEndian.Uint32(buf[off+4:])
+		rdlength := int(binary.BigEndian.Uint16(buf[off+8:]))
+		off += 10
+		if off+rdlength > len(buf) {
+			return nil, off, ErrShortBuffer
+		}
+		if rr.Data, err = unpackRdata(buf, off, rdlength, rr.Type); err != nil {
+			return nil, off, err
+		}
+		off += rdlength
+		rrs = append(rrs, rr)
+	}
+	return rrs, off, nil
+}
+
+/*
+Reads a possibly compressed name starting at off. Returns the name and the offset just past it.
+*/
+func unpackName(buf []byte, off int) (string, int, error) {
+	var name strings.Builder
+	end := -1 // offset after the first pointer, which is where the caller continues
+	hops := 0
+	for {
+		if off >= len(buf) {
+			return "", 0, ErrShortBuffer
+		}
+		length := int(buf[off])
+		switch length & compressionFlags {
+		case 0x00:
+			off++
+			if length == 0 {
+				if end < 0 {
+					end = off
+				}
+				if name.Len() == 0 {
+					return ".", end, nil
+				}
+				return name.String(), end, nil
+			}
+			if off+length > len(buf) {
+				return "", 0, ErrShortBuffer
+			}
+			name.Write(buf[off : off+length])
+			name.WriteByte('.')
+			if name.Len() > maxNameLen {
+				return "", 0, ErrNameLen
+			}
+			off += length
+		case compressionFlags:
+			if off+1 >= len(buf) {
+				return "", 0, ErrShortBuffer
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			// Pointers may only point backwards, which also bounds the number of hops.
+			ptr := int(binary.BigEndian.Uint16(buf[off:]) & maxCompressPtr)
+			if ptr >= off || hops > len(buf)/2 {
+				return "", 0, ErrPointer
+			}
+			hops++
+			off = ptr
+		default:
+			return "", 0, ErrLabelLen
+		}
+	}
+}
+
+/*
+***************************************
+		UTILITY FUNCTIONS
+***************************************
+*/
+
+/*
+Returns name with a trailing dot.
+*/
+func Fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
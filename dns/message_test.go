@@ -0,0 +1,214 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// One record of every type the server answers, and one of a type it only passes through.
+var records = []RR{
+	{Name: "example.com.", Type: TypeA, Class: ClassINET, TTL: 300, Data: "192.0.2.1"},
+	{Name: "example.com.", Type: TypeAAAA, Class: ClassINET, TTL: 300, Data: "2001:db8::1"},
+	{Name: "example.com.", Type: TypeNS, Class: ClassINET, TTL: 86400, Data: "ns1.example.com."},
+	{Name: "www.example.com.", Type: TypeCNAME, Class: ClassINET, TTL: 60, Data: "example.com."},
+	{Name: "1.2.0.192.in-addr.arpa.", Type: TypePTR, Class: ClassINET, TTL: 3600, Data: "host.example.com."},
+	{Name: "example.com.", Type: TypeMX, Class: ClassINET, TTL: 300, Data: "10 mail.example.com."},
+	{Name: "example.com.", Type: TypeTXT, Class: ClassINET, TTL: 300, Data: `"v=spf1 -all" "say \"hi\" \\ bye" "\009\255"`},
+	{Name: "_sip._tcp.example.com.", Type: TypeSRV, Class: ClassINET, TTL: 300, Data: "10 5 5060 sip.example.com."},
+	{Name: "example.com.", Type: TypeSOA, Class: ClassINET, TTL: 3600, Data: "ns1.example.com. admin.example.com. 2024010101 7200 3600 1209600 300"},
+	{Name: "example.com.", Type: 99, Class: ClassINET, TTL: 300, Data: `\# 4 0a000001`},
+}
+
+func TestPackUnpack(t *testing.T) {
+	for _, rr := range records {
+		msg := &Message{
+			Header: Header{
+				ID:                 0xBEEF,
+				Response:           true,
+				Opcode:             OpcodeQuery,
+				Authoritative:      true,
+				RecursionDesired:   true,
+				RecursionAvailable: true,
+				Rcode:              RcodeSuccess,
+			},
+			Questions:  []Question{{Name: rr.Name, Type: rr.Type, Class: ClassINET}},
+			Answers:    []RR{rr},
+			Authority:  []RR{records[2]},
+			Additional: []RR{records[0]},
+		}
+		buf, err := msg.Pack()
+		if err != nil {
+			t.Fatalf("%s: %v", TypeString(rr.Type), err)
+		}
+		got, err := Unpack(buf)
+		if err != nil {
+			t.Fatalf("%s: %v", TypeString(rr.Type), err)
+		}
+		if !reflect.DeepEqual(got, msg) {
+			t.Errorf("%s: unpacked\n%+v\nwant\n%+v", TypeString(rr.Type), got, msg)
+		}
+	}
+}
+
+func TestPackFlags(t *testing.T) {
+	for _, h := range []Header{
+		{ID: 1, Truncated: true},
+		{ID: 2, Opcode: 2, Rcode: RcodeRefused},
+		{ID: 0xFFFF, Response: true, Rcode: RcodeNameError},
+	} {
+		buf, err := (&Message{Header: h}).Pack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Unpack(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Header != h {
+			t.Errorf("unpacked header %+v, want %+v", got.Header, h)
+		}
+	}
+}
+
+func TestPackCompression(t *testing.T) {
+	msg := &Message{
+		Questions: []Question{{Name: "example.com.", Type: TypeMX, Class: ClassINET}},
+		Answers:   []RR{{Name: "example.com.", Type: TypeMX, Class: ClassINET, TTL: 300, Data: "10 mail.example.com."}},
+	}
+	buf, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The question name is written at offset 12, and every later occurrence points to it.
+	answer := headerLen + len("\x07example\x03com\x00") + 4
+	if !bytes.Equal(buf[answer:answer+2], []byte{0xC0, 12}) {
+		t.Errorf("owner name is % x, want a pointer to offset 12", buf[answer:answer+2])
+	}
+	rdata := answer + 2 + 10
+	if want := []byte{0, 10, 4, 'm', 'a', 'i', 'l', 0xC0, 12}; !bytes.Equal(buf[rdata:], want) {
+		t.Errorf("MX rdata is % x, want % x", buf[rdata:], want)
+	}
+	if len(buf) != rdata+9 {
+		t.Errorf("packed %d octets, want %d", len(buf), rdata+9)
+	}
+}
+
+func TestUnpackName(t *testing.T) {
+	long := func(c byte) string { return "\x3F" + strings.Repeat(string(c), 63) }
+	for _, test := range []struct {
+		desc string
+		buf  string
+		off  int
+		name string
+		next int
+		err  error
+	}{
+		{"root", "\x00", 0, ".", 1, nil},
+		{"labels", "\x03www\x07example\x03com\x00", 0, "www.example.com.", 17, nil},
+		{"pointer", "\x03com\x00\x03www\xC0\x00", 5, "www.com.", 11, nil},
+		{"pointer to pointer", "\x03com\x00\x01a\xC0\x00\x01b\xC0\x05", 9, "b.a.com.", 13, nil},
+		{"pointer to itself", "\xC0\x00", 0, "", 0, ErrPointer},
+		{"forward pointer", "\xC0\x02\x00", 0, "", 0, ErrPointer},
+		{"pointer past the end", "\x00\xC0\xFF", 1, "", 0, ErrPointer},
+		{"largest pointer", "\x00\xFF\xFF", 1, "", 0, ErrPointer},
+		// The second pointer goes back to the first one, which is only reachable by going forward.
+		{"pointer loop", "\x01a\xC0\x04\xC0\x00", 4, "", 0, ErrPointer},
+		{"truncated pointer", "\x00\xC0", 1, "", 0, ErrShortBuffer},
+		{"truncated label", "\x05ab", 0, "", 0, ErrShortBuffer},
+		{"missing root label", "\x03com", 0, "", 0, ErrShortBuffer},
+		{"offset past the end", "\x00", 1, "", 0, ErrShortBuffer},
+		{"reserved label type", "\x40", 0, "", 0, ErrLabelLen},
+		{"extended label type", "\x80", 0, "", 0, ErrLabelLen},
+		{"name too long", long('a') + "\x00" + long('b') + "\xC0\x00" + long('c') + "\xC0\x41" + long('d') + "\xC0\x83", 0xC5, "", 0, ErrNameLen},
+	} {
+		name, next, err := unpackName([]byte(test.buf), test.off)
+		if !errors.Is(err, test.err) || name != test.name || next != test.next {
+			t.Errorf("%s: got %q, %d, %v, want %q, %d, %v", test.desc, name, next, err, test.name, test.next, test.err)
+		}
+	}
+}
+
+func TestUnpackMalformed(t *testing.T) {
+	header := "\x12\x34\x01\x00\x00\x01\x00\x01\x00\x00\x00\x00"
+	question := "\xC0\x0C\x00\x01\x00\x01"
+	for _, test := range []struct {
+		desc string
+		buf  string
+		err  error
+	}{
+		{"short header", header[:11], ErrShortBuffer},
+		{"question name pointing to itself", header + question, ErrPointer},
+		{"question name past the end", header + "\xC0\xFF\x00\x01\x00\x01", ErrPointer},
+		{"missing question", header, ErrShortBuffer},
+		{"truncated question", header + "\x00\x00\x01", ErrShortBuffer},
+		{"missing answer", header + "\x00\x00\x01\x00\x01", ErrShortBuffer},
+		{"rdata past the end", header + "\x00\x00\x01\x00\x01" + "\x00\x00\x01\x00\x01\x00\x00\x00\x3C\x00\x04\x01", ErrShortBuffer},
+		{"A rdata of 3 octets", header + "\x00\x00\x01\x00\x01" + "\x00\x00\x01\x00\x01\x00\x00\x00\x3C\x00\x03\x01\x02\x03", ErrRdata},
+		{"CNAME rdata pointing forward", header + "\x00\x00\x05\x00\x01" + "\x00\x00\x05\x00\x01\x00\x00\x00\x3C\x00\x02\xC0\x30", ErrRdata},
+		{"TXT string past the rdata", header + "\x00\x00\x10\x00\x01" + "\x00\x00\x10\x00\x01\x00\x00\x00\x3C\x00\x02\x05a", ErrRdata},
+	} {
+		if _, err := Unpack([]byte(test.buf)); !errors.Is(err, test.err) {
+			t.Errorf("%s: got %v, want %v", test.desc, err, test.err)
+		}
+	}
+}
+
+func TestUnpackTruncated(t *testing.T) {
+	msg := &Message{
+		Header:    Header{ID: 7, Response: true},
+		Questions: []Question{{Name: "example.com.", Type: TypeA, Class: ClassINET}},
+		Answers:   records,
+	}
+	buf, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every prefix of a message misses part of a section its header counts.
+	for n := 0; n < len(buf); n++ {
+		if _, err := Unpack(buf[:n]); err == nil {
+			t.Errorf("unpacked the first %d of %d octets", n, len(buf))
+		}
+	}
+}
+
+func TestUnpackOPT(t *testing.T) {
+	// A query advertising a payload size of 1232, with the DO bit and a cookie option (RFC 6891 6.1.2).
+	buf := []byte("\xAB\xCD\x01\x00\x00\x01\x00\x00\x00\x00\x00\x01" +
+		"\x07example\x03com\x00\x00\x01\x00\x01" +
+		"\x00\x00\x29\x04\xD0\x00\x00\x80\x00\x00\x0C\x00\x0A\x00\x08\x01\x02\x03\x04\x05\x06\x07\x08")
+	msg, err := Unpack(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RR{Name: ".", Type: TypeOPT, Class: 1232, TTL: 0x8000}
+	if len(msg.Additional) != 1 || msg.Additional[0] != want {
+		t.Fatalf("additional section is %+v, want %+v", msg.Additional, want)
+	}
+	if opt, ok := findOPT(msg); !ok || opt != want {
+		t.Errorf("findOPT returned %+v, %t", opt, ok)
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		rr   RR
+		err  error
+	}{
+		{"label too long", RR{Name: strings.Repeat("a", 64) + ".com.", Type: TypeA, Data: "192.0.2.1"}, ErrLabelLen},
+		{"empty label", RR{Name: "a..com.", Type: TypeA, Data: "192.0.2.1"}, ErrLabelLen},
+		{"name too long", RR{Name: strings.Repeat("abcdefg.", 32), Type: TypeA, Data: "192.0.2.1"}, ErrNameLen},
+		{"IPv6 address in an A record", RR{Name: "a.", Type: TypeA, Data: "2001:db8::1"}, ErrRdata},
+		{"MX without preference", RR{Name: "a.", Type: TypeMX, Data: "mail.example.com."}, ErrRdata},
+		{"SOA serial out of range", RR{Name: "a.", Type: TypeSOA, Data: "ns. admin. 4294967296 1 1 1 1"}, ErrRdata},
+		{"unknown type without \\#", RR{Name: "a.", Type: 99, Data: "10.0.0.1"}, ErrRdata},
+		{"\\# with the wrong length", RR{Name: "a.", Type: 99, Data: `\# 3 0a000001`}, ErrRdata},
+	} {
+		if _, err := (&Message{Answers: []RR{test.rr}}).Pack(); !errors.Is(err, test.err) {
+			t.Errorf("%s: got %v, want %v", test.desc, err, test.err)
+		}
+	}
+}
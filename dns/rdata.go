@@ -0,0 +1,335 @@
+package dns
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+/*
+***************************************
+		RDATA
+***************************************
+*/
+
+/*
+RDATA is kept in presentation format (RFC 1035 5.1) so that records can be stored, printed and sent between
+nodes without caring about their type. These functions convert it to and from the wire format. Unknown
+types use the generic "\# <length> <hex>" notation of RFC 3597.
+*/
+
+var typeNames = map[uint16]string{
+	TypeA:     "A",
+	TypeNS:    "NS",
+	TypeCNAME: "CNAME",
+	TypeSOA:   "SOA",
+	TypePTR:   "PTR",
+	TypeMX:    "MX",
+	TypeTXT:   "TXT",
+	TypeAAAA:  "AAAA",
+	TypeSRV:   "SRV",
+	TypeOPT:   "OPT",
+	TypeANY:   "ANY",
+}
+
+/*
+Returns the mnemonic of a record type, e.g. "MX", or "TYPE<n>" for types without one.
+*/
+func TypeString(rrtype uint16) string {
+	if name, ok := typeNames[rrtype]; ok {
+		return name
+	}
+	return "TYPE" + strconv.Itoa(int(rrtype))
+}
+
+/*
+Parses a record type mnemonic (case insensitive) or "TYPE<n>". Returns false if it is neither.
+*/
+func ParseType(s string) (uint16, bool) {
+	s = strings.ToUpper(s)
+	for rrtype, name := range typeNames {
+		if name == s {
+			return rrtype, true
+		}
+	}
+	if n, err := strconv.ParseUint(strings.TrimPrefix(s, "TYPE"), 10, 16); err == nil && strings.HasPrefix(s, "TYPE") {
+		return uint16(n), true
+	}
+	return 0, false
+}
+
+/*
+Appends the wire format of data to buf. Names are compressed where RFC 3597 allows it.
+*/
+func packRdata(buf []byte, rrtype uint16, data string, compression map[string]int) ([]byte, error) {
+	if strings.HasPrefix(data, `\#`) {
+		return packUnknownRdata(buf, data)
+	}
+	fields := strings.Fields(data)
+	var err error
+	switch rrtype {
+	case TypeA, TypeAAAA:
+		ip := net.ParseIP(data)
+		if ip == nil {
+			return nil, ErrRdata
+		}
+		if rrtype == TypeAAAA {
+			return append(buf, ip.To16()...), nil
+		}
+		if ip = ip.To4(); ip == nil {
+			return nil, ErrRdata
+		}
+		return append(buf, ip...), nil
+	case TypeNS, TypeCNAME, TypePTR:
+		if len(fields) != 1 {
+			return nil, ErrRdata
+		}
+		return packName(buf, fields[0], compression)
+	case TypeMX:
+		if len(fields) != 2 {
+			return nil, ErrRdata
+		}
+		if buf, err = packUints(buf, fields[:1], 16); err != nil {
+			return nil, err
+		}
+		return packName(buf, fields[1], compression)
+	case TypeSRV:
+		if len(fields) != 4 {
+			return nil, ErrRdata
+		}
+		if buf, err = packUints(buf, fields[:3], 16); err != nil {
+			return nil, err
+		}
+		// The target of an SRV record must not be compressed (RFC 2782).
+		return packName(buf, fields[3], nil)
+	case TypeSOA:
+		if len(fields) != 7 {
+			return nil, ErrRdata
+		}
+		if buf, err = packName(buf, fields[0], compression); err != nil {
+			return nil, err
+		}
+		if buf, err = packName(buf, fields[1], compression); err != nil {
+			return nil, err
+		}
+		return packUints(buf, fields[2:], 32)
+	case TypeTXT:
+		strs, err := splitTXT(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, str := range strs {
+			if len(str) > 255 {
+				return nil, ErrRdata
+			}
+			buf = append(buf, byte(len(str)))
+			buf = append(buf, str...)
+		}
+		return buf, nil
+	case TypeOPT:
+		if data != "" {
+			return nil, ErrRdata
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("%w: %s needs \\# notation", ErrRdata, TypeString(rrtype))
+}
+
+func packUnknownRdata(buf []byte, data string) ([]byte, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 2 {
+		return nil, ErrRdata
+	}
+	length, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, ErrRdata
+	}
+	raw, err := hex.DecodeString(strings.Join(fields[2:], ""))
+	if err != nil || len(raw) != length {
+		return nil, ErrRdata
+	}
+	return append(buf, raw...), nil
+}
+
+func packUints(buf []byte, fields []string, bits int) ([]byte, error) {
+	for _, field := range fields {
+		n, err := strconv.ParseUint(field, 10, bits)
+		if err != nil {
+			return nil, ErrRdata
+		}
+		if bits == 16 {
+			buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+		} else {
+			buf = binary.BigEndian.AppendUint32(buf, uint32(n))
+		}
+	}
+	return buf, nil
+}
+
+/*
+Returns the presentation format of the rdlength octets of RDATA at buf[off:]. The whole message is needed
+because names inside RDATA may be compressed.
+*/
+func unpackRdata(buf []byte, off, rdlength int, rrtype uint16) (string, error) {
+	end := off + rdlength
+	rdata := buf[off:end]
+	switch rrtype {
+	case TypeA:
+		if rdlength != net.IPv4len {
+			return "", ErrRdata
+		}
+		return net.IP(rdata).String(), nil
+	case TypeAAAA:
+		if rdlength != net.IPv6len {
+			return "", ErrRdata
+		}
+		return net.IP(rdata).String(), nil
+	case TypeNS, TypeCNAME, TypePTR:
+		name, next, err := unpackName(buf, off)
+		if err != nil || next != end {
+			return "", ErrRdata
+		}
+		return name, nil
+	case TypeMX:
+		if rdlength < 3 {
+			return "", ErrRdata
+		}
+		name, next, err := unpackName(buf, off+2)
+		if err != nil || next != end {
+			return "", ErrRdata
+		}
+		return fmt.Sprintf("%d %s", binary.BigEndian.Uint16(rdata), name), nil
+	case TypeSRV:
+		if rdlength < 7 {
+			return "", ErrRdata
+		}
+		name, next, err := unpackName(buf, off+6)
+		if err != nil || next != end {
+			return "", ErrRdata
+		}
+		return fmt.Sprintf("%d %d %d %s", binary.BigEndian.Uint16(rdata), binary.BigEndian.Uint16(rdata[2:]),
+			binary.BigEndian.Uint16(rdata[4:]), name), nil
+	case TypeSOA:
+		mname, next, err := unpackName(buf, off)
+		if err != nil {
+			return "", ErrRdata
+		}
+		rname, next, err := unpackName(buf, next)
+		if err != nil || next+20 != end {
+			return "", ErrRdata
+		}
+		nums := buf[next:end]
+		return fmt.Sprintf("%s %s %d %d %d %d %d", mname, rname, binary.BigEndian.Uint32(nums),
+			binary.BigEndian.Uint32(nums[4:]), binary.BigEndian.Uint32(nums[8:]),
+			binary.BigEndian.Uint32(nums[12:]), binary.BigEndian.Uint32(nums[16:])), nil
+	case TypeTXT:
+		var strs []string
+		for i := 0; i < rdlength; {
+			length := int(rdata[i])
+			if i+1+length > rdlength {
+				return "", ErrRdata
+			}
+			strs = append(strs, quoteTXT(rdata[i+1:i+1+length]))
+			i += 1 + length
+		}
+		return strings.Join(strs, " "), nil
+	case TypeOPT:
+		// Options are not interpreted, but their presence should not fail the whole message.
+		return "", nil
+	}
+	return fmt.Sprintf(`\# %d %x`, rdlength, rdata), nil
+}
+
+/*
+Returns the presentation format of a TXT record holding txt, split into character-strings of at most 255 octets.
+*/
+func TXTData(txt string) string {
+	var strs []string
+	for len(txt) > 255 {
+		strs = append(strs, quoteTXT([]byte(txt[:255])))
+		txt = txt[255:]
+	}
+	strs = append(strs, quoteTXT([]byte(txt)))
+	return strings.Join(strs, " ")
+}
+
+/*
+Quotes a character-string, escaping quotes, backslashes and non printable octets.
+*/
+func quoteTXT(str []byte) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range str {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+/*
+Splits the presentation format of a TXT record into its character-strings, undoing quoteTXT. Unquoted
+words are accepted as separate strings.
+*/
+func splitTXT(data string) ([]string, error) {
+	var strs []string
+	for i := 0; i < len(data); {
+		if data[i] == ' ' || data[i] == '\t' {
+			i++
+			continue
+		}
+		quoted := data[i] == '"'
+		if quoted {
+			i++
+		}
+		var str []byte
+		for ; i < len(data); i++ {
+			c := data[i]
+			if quoted && c == '"' {
+				i++
+				break
+			}
+			if !quoted && (c == ' ' || c == '\t') {
+				break
+			}
+			if c == '\\' {
+				if i+4 <= len(data) && isDigits(data[i+1:i+4]) {
+					n, _ := strconv.Atoi(data[i+1 : i+4])
+					if n > 255 {
+						return nil, ErrRdata
+					}
+					str = append(str, byte(n))
+					i += 3
+					continue
+				}
+				if i+1 >= len(data) {
+					return nil, ErrRdata
+				}
+				i++
+				c = data[i]
+			}
+			str = append(str, c)
+		}
+		strs = append(strs, string(str))
+	}
+	return strs, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,247 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Errors a Resolver can return (possibly wrapped) to select the response code.
+var (
+	ErrNameError = errors.New("dns: name does not exist") // Answered with NXDOMAIN.
+	ErrRefused   = errors.New("dns: query refused")       // Answered with REFUSED.
+)
+
+const tcpTimeout = 10 * time.Second
+
+// Query types that are answered. Everything else is refused.
+var supportedTypes = map[uint16]bool{
+	TypeA: true, TypeAAAA: true, TypeCNAME: true, TypeMX: true, TypeTXT: true,
+	TypeNS: true, TypeSRV: true, TypePTR: true, TypeSOA: true,
+}
+
+/*
+Anything that can look up the records of a given type for a domain name. The name is passed lower case and
+without the trailing dot. The returned answer records may start with a CNAME chain. The authority records
+should hold the SOA record of negative answers (NXDOMAIN, or NODATA when there are no answers), so that
+clients can cache them (RFC 2308). Any error that does not wrap ErrNameError or ErrRefused is reported to
+the client as SERVFAIL.
+*/
+type Resolver interface {
+	Resolve(name string, rrtype uint16) (answers []RR, authority []RR, err error)
+}
+
+/*
+Serves DNS over UDP and TCP on the same address, and answers every question through Resolver.
+*/
+type Server struct {
+	Addr     string
+	Resolver Resolver
+}
+
+/*
+Binds to Addr on both UDP and TCP and serves queries in the background.
+*/
+func (srv *Server) ListenAndServe() error {
+	udpAddr, err := net.ResolveUDPAddr("udp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	udpConn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	tcpListener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		udpConn.Close()
+		return err
+	}
+	log.Info().Msgf("DNS server is running at address: %s (udp/tcp)", srv.Addr)
+	go srv.serveUDP(udpConn)
+	go srv.serveTCP(tcpListener)
+	return nil
+}
+
+func (srv *Server) serveUDP(conn *net.UDPConn) {
+	buf := make([]byte, MaxUDPSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.Error().Err(err).Msg("Error reading DNS query over UDP")
+			return
+		}
+		query := append([]byte(nil), buf[:n]...)
+		go func() {
+			reply := srv.handle(query, true)
+			if reply == nil {
+				return
+			}
+			if _, err := conn.WriteToUDP(reply, addr); err != nil {
+				log.Error().Err(err).Msg("Error writing DNS response over UDP")
+			}
+		}()
+	}
+}
+
+func (srv *Server) serveTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Error().Err(err).Msg("Error accepting DNS connection over TCP")
+			return
+		}
+		go srv.serveTCPConn(conn)
+	}
+}
+
+/*
+Serves length prefixed queries (RFC 1035 4.2.2) until the client closes the connection or goes idle.
+*/
+func (srv *Server) serveTCPConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		conn.SetDeadline(time.Now().Add(tcpTimeout))
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		reply := srv.handle(query, false)
+		if reply == nil {
+			return
+		}
+		out := binary.BigEndian.AppendUint16(make([]byte, 0, len(reply)+2), uint16(len(reply)))
+		if _, err := conn.Write(append(out, reply...)); err != nil {
+			return
+		}
+	}
+}
+
+/*
+Builds the wire format response to a wire format query. Returns nil if the query is not even worth a
+FORMERR, for instance because it is a response or the header is incomplete.
+*/
+func (srv *Server) handle(query []byte, udp bool) []byte {
+	req, err := Unpack(query)
+	if err != nil {
+		if len(query) < headerLen {
+			return nil
+		}
+		// Echo the ID so that the client can match the error to its query.
+		return packError(binary.BigEndian.Uint16(query), RcodeFormatError)
+	}
+	if req.Response {
+		return nil
+	}
+
+	resp := srv.answer(req)
+
+	maxSize := MinUDPSize
+	if opt, ok := findOPT(req); ok {
+		// The class of an OPT record carries the requestor's UDP payload size (RFC 6891 6.1.2).
+		maxSize = min(max(int(opt.Class), MinUDPSize), MaxUDPSize)
+		resp.Additional = append(resp.Additional, RR{Name: ".", Type: TypeOPT, Class: MaxUDPSize})
+	}
+	reply, err := resp.Pack()
+	if err != nil {
+		log.Error().Err(err).Msg("Error packing DNS response")
+		return packError(req.ID, RcodeServerFailure)
+	}
+	if udp && len(reply) > maxSize {
+		// Let the client know it should retry over TCP.
+		resp.Truncated = true
+		resp.Answers, resp.Authority = nil, nil
+		if reply, err = resp.Pack(); err != nil {
+			return packError(req.ID, RcodeServerFailure)
+		}
+	}
+	return reply
+}
+
+/*
+Resolves the question in req, and returns the response message with the appropriate RCODE.
+*/
+func (srv *Server) answer(req *Message) *Message {
+	resp := &Message{Header: Header{
+		ID:                 req.ID,
+		Response:           true,
+		Opcode:             req.Opcode,
+		RecursionDesired:   req.RecursionDesired,
+		RecursionAvailable: true,
+	}}
+	if req.Opcode != OpcodeQuery {
+		resp.Rcode = RcodeNotImplemented
+		return resp
+	}
+	if len(req.Questions) != 1 {
+		resp.Rcode = RcodeFormatError
+		return resp
+	}
+	q := req.Questions[0]
+	resp.Questions = []Question{q}
+	if q.Class != ClassINET && q.Class != ClassANY {
+		resp.Rcode = RcodeRefused
+		return resp
+	}
+	qtype := q.Type
+	if qtype == TypeANY {
+		// Answering ANY with a subset of the records is allowed by RFC 8482.
+		qtype = TypeA
+	}
+	if !supportedTypes[qtype] {
+		resp.Rcode = RcodeRefused
+		return resp
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(q.Name, "."))
+	if name == "" {
+		resp.Rcode = RcodeRefused
+		return resp
+	}
+	answers, authority, err := srv.Resolver.Resolve(name, qtype)
+	switch {
+	case errors.Is(err, ErrNameError):
+		resp.Rcode = RcodeNameError
+		resp.Authority = authority
+		return resp
+	case errors.Is(err, ErrRefused):
+		resp.Rcode = RcodeRefused
+		return resp
+	case err != nil:
+		log.Error().Err(err).Msgf("Could not resolve %s", name)
+		resp.Rcode = RcodeServerFailure
+		return resp
+	}
+
+	resp.Answers = answers
+	if len(answers) == 0 {
+		resp.Authority = authority
+	}
+	return resp
+}
+
+/*
+Returns the EDNS0 OPT pseudo record of the message, if there is one.
+*/
+func findOPT(msg *Message) (RR, bool) {
+	for _, rr := range msg.Additional {
+		if rr.Type == TypeOPT {
+			return rr, true
+		}
+	}
+	return RR{}, false
+}
+
+func packError(id uint16, rcode int) []byte {
+	msg := &Message{Header: Header{ID: id, Response: true, Rcode: rcode}}
+	reply, _ := msg.Pack()
+	return reply
+}
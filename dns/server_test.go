@@ -0,0 +1,166 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Resolver answering through a function.
+type resolverFunc func(name string, rrtype uint16) ([]RR, []RR, error)
+
+func (f resolverFunc) Resolve(name string, rrtype uint16) ([]RR, []RR, error) {
+	return f(name, rrtype)
+}
+
+var soa = RR{Name: "example.com.", Type: TypeSOA, Class: ClassINET, TTL: 3600, Data: "ns1.example.com. admin.example.com. 1 7200 3600 1209600 300"}
+
+// Answers every name with n A records, after checking that it gets the name in the form Resolver documents.
+func addresses(t *testing.T, n int) Resolver {
+	return resolverFunc(func(name string, rrtype uint16) ([]RR, []RR, error) {
+		if name != "example.com" {
+			t.Errorf("resolver got %q, want example.com", name)
+		}
+		var answers []RR
+		for i := 0; i < n; i++ {
+			answers = append(answers, RR{Name: "example.com.", Type: rrtype, Class: ClassINET, TTL: 300, Data: fmt.Sprintf("192.0.2.%d", i)})
+		}
+		return answers, nil, nil
+	})
+}
+
+// Sends req to srv as if it came over UDP or TCP, and returns the size of the reply and the reply.
+func exchange(t *testing.T, srv *Server, req *Message, udp bool) (int, *Message) {
+	t.Helper()
+	query, err := req.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := srv.handle(query, udp)
+	resp, err := Unpack(reply)
+	if err != nil {
+		t.Fatalf("could not unpack the reply: %v", err)
+	}
+	return len(reply), resp
+}
+
+func question(name string, qtype uint16) *Message {
+	return &Message{
+		Header:    Header{ID: 0x1234, RecursionDesired: true},
+		Questions: []Question{{Name: name, Type: qtype, Class: ClassINET}},
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	srv := &Server{Resolver: addresses(t, 2)}
+	_, resp := exchange(t, srv, question("Example.COM.", TypeA), true)
+	want := Header{ID: 0x1234, Response: true, RecursionDesired: true, RecursionAvailable: true, Rcode: RcodeSuccess}
+	if resp.Header != want {
+		t.Errorf("header is %+v, want %+v", resp.Header, want)
+	}
+	if len(resp.Questions) != 1 || resp.Questions[0].Name != "Example.COM." {
+		t.Errorf("questions are %+v, want the one asked", resp.Questions)
+	}
+	if len(resp.Answers) != 2 || len(resp.Additional) != 0 {
+		t.Errorf("got %d answers and %d additional records, want 2 and 0", len(resp.Answers), len(resp.Additional))
+	}
+}
+
+func TestRcode(t *testing.T) {
+	fails := func(err error) Resolver {
+		return resolverFunc(func(string, uint16) ([]RR, []RR, error) { return nil, []RR{soa}, err })
+	}
+	chaos := question("example.com.", TypeA)
+	chaos.Questions[0].Class = 3
+	notify := question("example.com.", TypeSOA)
+	notify.Opcode = 4
+	two := question("example.com.", TypeA)
+	two.Questions = append(two.Questions, two.Questions[0])
+	tests := []struct {
+		desc      string
+		resolver  Resolver
+		req       *Message
+		rcode     int
+		authority int
+	}{
+		{"name error", fails(fmt.Errorf("lookup: %w", ErrNameError)), question("example.com.", TypeA), RcodeNameError, 1},
+		{"no data", fails(nil), question("example.com.", TypeMX), RcodeSuccess, 1},
+		{"refused by the resolver", fails(fmt.Errorf("lookup: %w", ErrRefused)), question("example.com.", TypeA), RcodeRefused, 0},
+		{"resolver failure", fails(errors.New("upstream timed out")), question("example.com.", TypeA), RcodeServerFailure, 0},
+		{"unsupported type", addresses(t, 1), question("example.com.", TypeOPT), RcodeRefused, 0},
+		{"unknown type", addresses(t, 1), question("example.com.", 99), RcodeRefused, 0},
+		{"root name", addresses(t, 1), question(".", TypeA), RcodeRefused, 0},
+		{"ANY", addresses(t, 1), question("example.com.", TypeANY), RcodeSuccess, 0},
+		{"CHAOS class", addresses(t, 1), chaos, RcodeRefused, 0},
+		{"NOTIFY opcode", addresses(t, 1), notify, RcodeNotImplemented, 0},
+		{"two questions", addresses(t, 1), two, RcodeFormatError, 0},
+	}
+
+	for _, test := range tests {
+		_, resp := exchange(t, &Server{Resolver: test.resolver}, test.req, true)
+		if resp.Rcode != test.rcode || len(resp.Authority) != test.authority || resp.ID != test.req.ID {
+			t.Errorf("%s: got rcode %d with %d authority records and ID %#x, want %d with %d and %#x", test.desc,
+				resp.Rcode, len(resp.Authority), resp.ID, test.rcode, test.authority, test.req.ID)
+		}
+	}
+}
+
+func TestMalformedQuery(t *testing.T) {
+	srv := &Server{Resolver: addresses(t, 1)}
+	if reply := srv.handle([]byte{0x12, 0x34, 0x01}, true); reply != nil {
+		t.Errorf("answered an incomplete header with % x", reply)
+	}
+	// A question whose name points to itself
+	resp, err := Unpack(srv.handle([]byte("\x12\x34\x01\x00\x00\x01\x00\x00\x00\x00\x00\x00\xC0\x0C\x00\x01\x00\x01"), true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Rcode != RcodeFormatError || resp.ID != 0x1234 || !resp.Response {
+		t.Errorf("got %+v, want a FORMERR response with ID 0x1234", resp.Header)
+	}
+	response := question("example.com.", TypeA)
+	response.Response = true
+	query, _ := response.Pack()
+	if reply := srv.handle(query, true); reply != nil {
+		t.Errorf("answered a response with % x", reply)
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	// 40 A records take 669 octets: more than a client without EDNS0 accepts, less than 1232.
+	srv := &Server{Resolver: addresses(t, 40)}
+	withOPT := func(size uint16) *Message {
+		req := question("example.com.", TypeA)
+		req.Additional = []RR{{Name: ".", Type: TypeOPT, Class: size}}
+		return req
+	}
+	for _, test := range []struct {
+		desc      string
+		req       *Message
+		udp       bool
+		truncated bool
+		maxSize   int
+	}{
+		{"UDP", question("example.com.", TypeA), true, true, MinUDPSize},
+		{"TCP", question("example.com.", TypeA), false, false, maxMessageLen},
+		{"UDP with EDNS0", withOPT(1232), true, false, 1232},
+		{"UDP with EDNS0 below 512", withOPT(100), true, true, MinUDPSize},
+		{"UDP with EDNS0 above our limit", withOPT(65000), true, false, MaxUDPSize},
+	} {
+		size, resp := exchange(t, srv, test.req, test.udp)
+		if size > test.maxSize {
+			t.Errorf("%s: reply of %d octets, more than %d", test.desc, size, test.maxSize)
+		}
+		if resp.Truncated != test.truncated {
+			t.Errorf("%s: truncated is %t, want %t", test.desc, resp.Truncated, test.truncated)
+		}
+		if answers := len(resp.Answers); test.truncated && answers != 0 || !test.truncated && answers != 40 {
+			t.Errorf("%s: got %d answers", test.desc, answers)
+		}
+		// The reply advertises our own payload size to clients that use EDNS0, even when truncated.
+		opt, ok := findOPT(resp)
+		if hasOPT := len(test.req.Additional) > 0; ok != hasOPT || ok && opt.Class != MaxUDPSize {
+			t.Errorf("%s: OPT record of the reply is %+v, %t", test.desc, opt, ok)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package dns
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultUpstreamTimeout = 2 * time.Second
+	maxUpstreamFailures    = 3                // Consecutive failures after which an upstream is considered down.
+	upstreamBackoff        = 30 * time.Second // How long an upstream that is down is only used as a last resort.
+)
+
+var ErrNoUpstream = errors.New("dns: no upstream server answered")
+
+/*
+Health of one upstream server, as reported by Upstream.Health.
+*/
+type UpstreamHealth struct {
+	Addr      string
+	Queries   uint64        // Queries sent to the server.
+	Failures  uint64        // Queries that timed out, failed, or were answered with SERVFAIL, REFUSED and the like.
+	LastRTT   time.Duration // Round trip time of the last successful query.
+	Down      bool          // Too many consecutive failures. Only tried once every other server has failed.
+	LastError string
+}
+
+type upstreamServer struct {
+	UpstreamHealth
+	consecutive int
+	downUntil   time.Time
+}
+
+/*
+A list of recursive DNS servers that are queried in order, failing over to the next one when a server
+times out or cannot answer. Servers that keep failing are moved to the back of the list for a while.
+Safe for concurrent use.
+*/
+type Upstream struct {
+	mu      sync.Mutex
+	servers []*upstreamServer
+	timeout time.Duration
+}
+
+/*
+Creates an Upstream querying addrs ("host" or "host:port", port 53 by default) in order. timeout bounds
+each attempt; DefaultUpstreamTimeout is used if it is not positive.
+*/
+func NewUpstream(addrs []string, timeout time.Duration) *Upstream {
+	if timeout <= 0 {
+		timeout = DefaultUpstreamTimeout
+	}
+	upstream := &Upstream{timeout: timeout}
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(strings.Trim(addr, "[]"), "53")
+		}
+		upstream.servers = append(upstream.servers, &upstreamServer{UpstreamHealth: UpstreamHealth{Addr: addr}})
+	}
+	return upstream
+}
+
+/*
+Returns the nameservers listed in a resolv.conf file, e.g. /etc/resolv.conf.
+*/
+func ReadResolvConf(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var servers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			// Drop the zone of link local addresses, e.g. fe80::1%eth0
+			servers = append(servers, strings.SplitN(fields[1], "%", 2)[0])
+		}
+	}
+	return servers, scanner.Err()
+}
+
+/*
+Queries the servers for (name, qtype), and returns the first response that is a definite answer:
+NOERROR (possibly without answers) or NXDOMAIN.
+*/
+func (u *Upstream) Query(name string, qtype uint16) (*Message, error) {
+	var errs []error
+	for _, server := range u.order() {
+		start := time.Now()
+		resp, err := Exchange(server.Addr, name, qtype, u.timeout)
+		if err == nil && resp.Rcode != RcodeSuccess && resp.Rcode != RcodeNameError {
+			err = fmt.Errorf("dns: upstream answered with rcode %d", resp.Rcode)
+		}
+		u.record(server, err, time.Since(start))
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", server.Addr, err))
+	}
+	return nil, fmt.Errorf("%w: %w", ErrNoUpstream, errors.Join(errs...))
+}
+
+/*
+Returns the servers in the order they should be tried: servers that are up in configured order, then
+servers that are down.
+*/
+func (u *Upstream) order() []*upstreamServer {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+	var up, down []*upstreamServer
+	for _, server := range u.servers {
+		if server.Down && now.After(server.downUntil) {
+			// Give it another chance.
+			server.Down = false
+			server.consecutive = 0
+		}
+		if server.Down {
+			down = append(down, server)
+		} else {
+			up = append(up, server)
+		}
+	}
+	return append(up, down...)
+}
+
+func (u *Upstream) record(server *upstreamServer, err error, rtt time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	server.Queries++
+	if err == nil {
+		server.LastRTT = rtt
+		server.consecutive = 0
+		server.Down = false
+		return
+	}
+	server.Failures++
+	server.LastError = err.Error()
+	server.consecutive++
+	if server.consecutive >= maxUpstreamFailures {
+		server.Down = true
+		server.downUntil = time.Now().Add(upstreamBackoff)
+	}
+}
+
+/*
+Returns a snapshot of the health of every server, in configured order.
+*/
+func (u *Upstream) Health() []UpstreamHealth {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	health := make([]UpstreamHealth, len(u.servers))
+	for i, server := range u.servers {
+		health[i] = server.UpstreamHealth
+	}
+	return health
+}
@@ -6,16 +6,24 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
-	"net"
-	"net/rpc"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/fauzxan/dns-chord/v2/cache"
+	"github.com/fauzxan/dns-chord/v2/config"
+	"github.com/fauzxan/dns-chord/v2/dns"
+	"github.com/fauzxan/dns-chord/v2/message"
 	"github.com/fauzxan/dns-chord/v2/utility"
 
 	"github.com/fauzxan/dns-chord/v2/node"
+	"github.com/fauzxan/dns-chord/v2/ring"
 
 	"github.com/fatih/color"
 	"github.com/joho/godotenv"
@@ -39,6 +47,8 @@ func showmenu() {
 	system.Println("Press 3 to see the node storage")
 	system.Println("Press 4 to see the cache")
 	system.Println("Press 5 to query a website")
+	system.Println("Press 7 to see the upstream resolvers")
+	system.Println("Press l to leave the network and exit")
 	system.Println("Press m to see the menu")
 	system.Println("********************************")
 }
@@ -47,85 +57,185 @@ func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	// get port from cli arguments (specified by user)
+	// .env variables map onto the same settings as the flags and the config file
 	err := godotenv.Load()
 	if err != nil {
-		log.Error().Msg("Error getting env variables...")
+		log.Debug().Msg("No .env file loaded")
+	}
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-
-	var port string
-	var helperIp string
-
-	// Read your own port number and also the IP address of the other node, if new network
-	myIpAddress := utility.GetOutboundIP().String()
-	reader := bufio.NewReader(os.Stdin)
-	// read input from user
-	system.Println("Enter your port number:")
-	port, err = reader.ReadString('\n')
 	if err != nil {
-		log.Error().Err(err).Msg("Error reading input")
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+	if cfg.Interactive {
+		promptMissing(&cfg)
 	}
-	system.Println("Enter IP address and port used to join network:")
-	// read input from user
-	helperIp, err = reader.ReadString('\n')
+	logLevel, _ := zerolog.ParseLevel(cfg.LogLevel)
+	zerolog.SetGlobalLevel(logLevel)
+	if cfg.Successors < cfg.ReplicationFactor {
+		log.Warn().Msgf("Replicas are held by the successor list, only %d of %d will be kept", cfg.Successors, cfg.ReplicationFactor)
+	}
+	if n := cfg.ReplicationFactor + 1; cfg.ReadQuorum+cfg.WriteQuorum <= n {
+		log.Warn().Msgf("Reads of %d and writes of %d out of %d replicas do not overlap, reads may miss the last write", cfg.ReadQuorum, cfg.WriteQuorum, n)
+	}
+
+	// Other nodes reach us at the advertised address, which may differ from the one we bind to (NAT, Docker)
+	cfg.Advertise, err = utility.AdvertiseAddress(cfg.Listen, cfg.Advertise)
 	if err != nil {
-		log.Error().Err(err).Msg("Error reading input")
+		log.Fatal().Err(err).Msg("Invalid configuration")
 	}
 
-	var addr = myIpAddress + ":" + port
+	upstreams := cfg.Upstreams
+	if len(upstreams) == 0 {
+		upstreams, err = dns.ReadResolvConf("/etc/resolv.conf")
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not read /etc/resolv.conf")
+		}
+	}
 
 	// Create new Node object for yourself
 	me := node.Node{
-		Nodeid:        utility.GenerateHash(addr),
-		IP:            addr[:len(addr)-1],
-		CachedQuery:   make(map[uint64]node.LRUCache, 69),
-		HashIPStorage: make(map[uint64]map[uint64][]string, 69),
+		Nodeid:            ring.Hash(cfg.Advertise, cfg.IDBits),
+		IP:                cfg.Advertise,
+		CachedQuery:       cache.NewLRU[ring.ID, node.CacheEntry](cfg.CacheSize),
+		HashIPStorage:     make(map[ring.ID]map[ring.ID]message.RRSet, 69),
+		DataDir:           cfg.DataDir,
+		ReplicationFactor: cfg.ReplicationFactor,
+		SuccListLength:    cfg.Successors,
+		ReadQuorum:        cfg.ReadQuorum,
+		WriteQuorum:       cfg.WriteQuorum,
+		ReadRepair:        cfg.ReadRepair,
+		MaxHints:          cfg.MaxHints,
+		HintTTL:           cfg.HintTTL,
+		RPCTimeout:        cfg.RPCTimeout,
+		IDBits:            cfg.IDBits,
+	}
+	if len(upstreams) > 0 {
+		me.Upstream = dns.NewUpstream(upstreams, cfg.UpstreamTimeout)
 	}
 
-	log.Info().Str("Address", addr)
-	log.Info().Uint64("My id is", me.Nodeid)
+	// Virtual nodes are configured like this one, which is the first of them
+	host := node.NewHost(&me, cfg.HostedNodes())
 
-	// Bind yourself to a port and listen to it
-	tcpAddr, err := net.ResolveTCPAddr("tcp", me.IP)
-	if err != nil {
-		log.Error().Err(err).Msg("Error resolving TCP address")
-	}
-	inbound, err := net.ListenTCP("tcp", tcpAddr)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not listen to TCP address")
+	log.Info().Msgf("Advertised address: %s", me.IP)
+	log.Info().Msgf("My id is %s", me.Nodeid)
+	for _, vnode := range host.Nodes[1:] {
+		log.Info().Msgf("Virtual node %s has id %s", vnode.IP, vnode.Nodeid)
 	}
 
-	// Register RPC methods and accept incoming requests
-	rpc.Register(&me)
-	log.Info().Msgf("Node is running at IP address: %s", tcpAddr.String())
-	go rpc.Accept(inbound)
-
-	helperIp = helperIp[:len(helperIp)-1]
+	// Bind yourself to a port and serve the messages of other nodes
+	if err := host.Listen(cfg.Listen); err != nil {
+		log.Fatal().Err(err).Msg("Could not listen to TCP address")
+	}
+	log.Info().Msgf("Node is running at IP address: %s", cfg.Listen)
 
 	/*
 		When a node first joins, it checks if it is the first node, then creates a new
 		chord network, or joins an existing chord network accordingly.
 	*/
-	if len(strings.Split(helperIp, ":")) == 1 { // I am the only node in this network
-		me.CreateNetwork()
+	if len(cfg.Bootstrap) == 0 { // I am the only node in this network
+		if err := host.CreateNetwork(); err != nil {
+			log.Fatal().Err(err).Msg("Could not create the network")
+		}
 	} else {
-		me.JoinNetwork(helperIp)
+		joined := false
+		for _, peer := range cfg.Bootstrap {
+			err := host.JoinNetwork(peer)
+			if errors.Is(err, node.ErrIDCollision) {
+				// Every peer would find the same node
+				log.Fatal().Err(err).Msg("Could not join the network")
+			}
+			if err != nil {
+				log.Warn().Err(err).Msgf("Could not join through %s", peer)
+				continue
+			}
+			joined = true
+			break
+		}
+		if !joined {
+			log.Fatal().Msg("Could not join the network through any bootstrap peer")
+		}
+	}
+
+	// Serve standard DNS queries (e.g. `dig @<ip> -p <dns port> example.com`) through the chord network
+	if cfg.DNSListen != "" {
+		dnsServer := dns.Server{Addr: cfg.DNSListen, Resolver: &me}
+		if err := dnsServer.ListenAndServe(); err != nil {
+			log.Error().Err(err).Msg("Could not start DNS server")
+		}
 	}
 
+	// Run until we are asked to stop, then hand our keys off to the rest of the network
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	leave := make(chan struct{})
+	if cfg.Interactive {
+		go menu(&me, logLevel, leave)
+	}
+	select {
+	case sig := <-signals:
+		log.Info().Msgf("Received %s, shutting down", sig)
+	case <-leave:
+	}
+	if err := host.Leave(); err != nil {
+		log.Error().Err(err).Msg("Could not leave the network gracefully")
+	}
+}
+
+/*
+Asks the user for the settings that were not configured through flags, the config file or the environment.
+*/
+func promptMissing(cfg *config.Config) {
+	reader := bufio.NewReader(os.Stdin)
+	readLine := func(prompt string) string {
+		system.Println(prompt)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			log.Error().Err(err).Msg("Error reading input")
+		}
+		return strings.TrimSpace(input)
+	}
+	// Read your own port number and also the IP address of the other node, if new network
+	if !cfg.IsSet("listen") {
+		if port := readLine("Enter your port number:"); port != "" {
+			cfg.Listen = ":" + port
+		}
+	}
+	if !cfg.IsSet("bootstrap") {
+		if helperIp := readLine("Enter IP address and port used to join network:"); strings.Contains(helperIp, ":") {
+			cfg.Bootstrap = []string{helperIp}
+		}
+	}
+	if !cfg.IsSet("dns-listen") {
+		if dnsPort := readLine("Enter port number for the DNS server:"); dnsPort != "" {
+			cfg.DNSListen = ":" + dnsPort
+		}
+	}
+}
+
+/*
+Interactive menu, used to inspect the node and query websites. Returns when stdin is closed, or after
+closing leave when the user asks to leave the network.
+*/
+func menu(me *node.Node, logLevel zerolog.Level, leave chan<- struct{}) {
 	showmenu()
 	dataList, err := utility.ReadCSV("./website_data/" + "websites" + ".csv")
 	if err != nil {
-		fmt.Println("Error reading CSV:", err)
-		return
+		log.Warn().Err(err).Msg("Error reading CSV, option 6 is unavailable")
 	}
 	// Keep the parent thread alive
 	for {
 		time.Sleep(1000)
 		var input string
 		system.Println("********************************")
-		system.Println("     Enter 1, 2, 3, 4, 5, 6, m:    ")
+		system.Println("   Enter 1, 2, 3, 4, 5, 6, 7, l, m:   ")
 		system.Println("********************************")
-		fmt.Scanln(&input)
+		if _, err := fmt.Scanln(&input); errors.Is(err, io.EOF) {
+			log.Info().Msg("Stdin closed, the menu is no longer available")
+			return
+		}
 
 		switch input {
 		case "1":
@@ -145,28 +255,53 @@ func main() {
 			me.PrintCache()
 		case "5":
 			log.Info().Msg("Querying website:")
-			system.Println("Please type the website:")
+			system.Println("Please type the website, optionally followed by a record type (e.g. example.com MX):")
 			// Pause logging
 			zerolog.SetGlobalLevel(zerolog.Disabled)
-			fmt.Scanln(&input)
+			var typeInput string
+			fmt.Scanln(&input, &typeInput)
 			// Resume logging
-			zerolog.SetGlobalLevel(zerolog.InfoLevel)
-			me.QueryDNS(input)
+			zerolog.SetGlobalLevel(logLevel)
+			rrtype := dns.TypeA
+			if typeInput != "" {
+				parsed, ok := dns.ParseType(typeInput)
+				if !ok {
+					log.Warn().Msgf("Unknown record type %s", typeInput)
+					continue
+				}
+				rrtype = parsed
+			}
+			node.PrintQueryResult(me.QueryDNS(input, rrtype))
 		case "6":
 			log.Info().Msgf("Querying %v websites", numQueries)
 			// Pause logging
 			zerolog.SetGlobalLevel(zerolog.Disabled)
 			fmt.Scanln(&input)
 			// Resume logging
-			zerolog.SetGlobalLevel(zerolog.InfoLevel)
+			zerolog.SetGlobalLevel(logLevel)
 			start := time.Now().UnixMilli()
-			for _, query := range dataList[:numQueries] {
-				// log.Info().Msg(query)
-				me.QueryDNS(query)
+			sources := map[string]int{}
+			failures, totalHops := 0, 0
+			for _, query := range dataList[:min(numQueries, len(dataList))] {
+				result, err := me.QueryDNS(query, dns.TypeA)
+				if err != nil {
+					failures++
+					continue
+				}
+				sources[result.Source]++
+				totalHops += result.HopCount
 			}
 			end := time.Now().UnixMilli()
 			timeTaken := end - start
 			log.Info().Msgf("TIME %v", timeTaken)
+			log.Info().Msgf("SOURCES %v FAILURES %d TOTAL HOPS %d", sources, failures, totalHops)
+		case "7":
+			system.Println("Printing Upstream Resolvers:")
+			me.PrintUpstreams()
+		case "l":
+			system.Println("Leaving the network...")
+			close(leave)
+			return
 		case "m":
 			showmenu()
 		default:
@@ -0,0 +1,100 @@
+/*
+Merkle trees over sets of keys, used to find the keys two replicas disagree on without sending them all.
+
+The tree has a fixed shape: every inner node has Fanout children and the leaves are at depth Depth, so the
+trees of two replicas can be compared node by node. A key goes to the leaf given by the low bits of its ID,
+which are uniformly distributed since IDs are hashes. The hash of a node is the XOR of the hashes of the
+entries below it, so that entries can be added and removed in any order, each in O(Depth), and a tree can be
+kept up to date with the set rather than rebuilt.
+*/
+package merkle
+
+import (
+	"encoding/binary"
+
+	"github.com/fauzxan/dns-chord/v2/ring"
+)
+
+const (
+	Fanout = 16                       // Children of every inner node.
+	Depth  = 3                        // Level of the leaves, the root being at level 0.
+	Leaves = Fanout * Fanout * Fanout // Fanout^Depth.
+)
+
+/*
+Merkle tree over a set of entries. The zero Tree is not usable, see New. Not safe for concurrent use.
+*/
+type Tree struct {
+	levels [][]uint64 // Hashes of the nodes at each level, Fanout^level of them.
+}
+
+/*
+Creates the tree of an empty set.
+*/
+func New() *Tree {
+	t := &Tree{levels: make([][]uint64, Depth+1)}
+	for level, width := 0, 1; level <= Depth; level, width = level+1, width*Fanout {
+		t.levels[level] = make([]uint64, width)
+	}
+	return t
+}
+
+/*
+Returns a copy of the tree, which does not change with it.
+*/
+func (t *Tree) Clone() *Tree {
+	c := &Tree{levels: make([][]uint64, len(t.levels))}
+	for level, hashes := range t.levels {
+		c.levels[level] = append([]uint64(nil), hashes...)
+	}
+	return c
+}
+
+/*
+Returns the index of the leaf key goes to.
+*/
+func Leaf(key ring.ID) int {
+	return int(binary.BigEndian.Uint32(key[len(key)-4:]) % Leaves)
+}
+
+/*
+Adds an entry of the given hash under key, or removes it if it was added before.
+*/
+func (t *Tree) Toggle(key ring.ID, hash uint64) {
+	for level, i := Depth, Leaf(key); level >= 0; level, i = level-1, i/Fanout {
+		t.levels[level][i] ^= hash
+	}
+}
+
+/*
+Returns the hash of the whole set.
+*/
+func (t *Tree) Root() uint64 {
+	return t.levels[0][0]
+}
+
+/*
+Returns the hash of node i at level.
+*/
+func (t *Tree) Hash(level, i int) uint64 {
+	return t.levels[level][i]
+}
+
+/*
+Returns the hashes of the children of node i at level, which must be above the leaves. The slice is shared
+with the tree.
+*/
+func (t *Tree) Children(level, i int) []uint64 {
+	return t.levels[level+1][i*Fanout : (i+1)*Fanout]
+}
+
+/*
+Returns true if node i at level exists in a tree of this shape. Used to check the nodes named by a peer.
+*/
+func Valid(level, i int) bool {
+	width := 1
+	for l := 0; l < level; l++ {
+		width *= Fanout
+	}
+	return level >= 0 && level <= Depth && i >= 0 && i < width
+}
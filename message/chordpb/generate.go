@@ -0,0 +1,5 @@
+// Generated code of the protocol between nodes, see chord.proto. Regenerate it with go generate, which needs
+// buf, protoc-gen-go and protoc-gen-go-grpc in the PATH.
+package chordpb
+
+//go:generate buf generate --template buf.gen.yaml
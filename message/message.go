@@ -4,24 +4,70 @@ Defines the structure for the Request and Response messages.
 package message
 
 import (
+	"github.com/fauzxan/dns-chord/v2/ring"
 	"github.com/rs/zerolog/log"
 )
 
-// Sample message structure. To be replaced with a struct for protobuff
+/*
+Message types, sent in RequestMessage.Type. Replies to messages that succeed have type ACK, except for
+GET_SUCCESSOR, GET_PREDECESSOR and GET whose replies only carry a result.
+*/
+const (
+	PING                   = "ping"                   // Used to check predecessor.
+	ACK                    = "ack"                    // Used for general acknowledgements.
+	GET_SUCCESSOR          = "get_successor"          // Used in RPC call to get node.Successor
+	FIND_SUCCESSOR         = "find_successor"         // Used to find successor.
+	CLOSEST_PRECEDING_NODE = "closest_preceding_node" // Used to find the closest preceding node, given a successor id.
+	GET_PREDECESSOR        = "get_predecessor"        // Used to get the predecessor of some node.
+	NOTIFY                 = "notify"                 // Used to notify a node about a new predecessor.
+	PUT                    = "put"                    // Used to insert a DNS query.
+	GET                    = "get"                    // Used to retrieve a DNS record.
+	SHIFT                  = "shift"                  // Used to shift entries to a new predecessor when it joins.
+	EMPTY                  = "empty"                  // Placeholder or undefined message type or errenous communications.
+	REPLICATE              = "replicate"              // Used to replicate data.
+	LEAVE                  = "leave"                  // Used to hand off the keys of a leaving node to its successor.
+	SET_SUCCESSOR          = "set_successor"          // Used to tell the predecessor of a leaving node about its new successor.
+	FLUSH                  = "flush"                  // Used to drop the replicas of a node that left.
+	SYNC                   = "sync"                   // Used to compare the Merkle tree of a node's keys with its replicas.
+)
+
+/*
+A node of the ring. The zero Pointer is a node that is not known.
+*/
+type Pointer struct {
+	Nodeid ring.ID // ID of the pointed Node
+	IP     string  // Address of the pointed Node: host and port, where host may be a hostname or an IPv6 literal in brackets
+}
+
+/*
+Messages exchanged by nodes. Over the network they are carried by the typed RPCs of chordpb, or encoded with
+gob by net/rpc for nodes using the TCP transport.
+*/
 type RequestMessage struct {
-	Type     string // PING | SYNC | FIND_SUCCESSOR | CLOSEST_PRECEDING_NODE | PUT
-	TargetId uint64 // ID of the parameter node passed to the destination
-	IP       string // IP of the parameter node passed to the destination
-	Payload  map[uint64][]string
+	Type     string  // PING | SYNC | FIND_SUCCESSOR | CLOSEST_PRECEDING_NODE | PUT | LEAVE
+	TargetId ring.ID // ID of the parameter node passed to the destination
+	IP       string  // IP of the parameter node passed to the destination
+	Payload  map[ring.ID]RRSet
 	HopCount int
+	Sender   ring.ID             // ID of the sending node, for messages where it differs from TargetId (e.g. LEAVE)
+	Receiver string              // Address the message is sent to, which tells apart the virtual nodes of a process. Set by CallRPC.
+	Owner    ring.ID             // Node whose copy of the key a GET reads, if it is a replica held for it. The receiver's own if zero.
+	Level    int                 // Level of the Merkle tree Subtrees are at, on SYNC
+	Subtrees []int               // Nodes of the Merkle tree compared by SYNC
+	Hashes   []uint64            // Hashes of Subtrees in the tree of the sender, on SYNC
+	Drop     map[ring.ID]Version // Replicas a REPLICATE removes, unless their version changed since
 }
 
 type ResponseMessage struct {
-	Type          string // PING | SYNC | ACK | FIND_SUCCESSOR | CLOSEST_PRECEDING_NODE
-	Nodeid        uint64 // ID of the node in the response message
-	IP            string // IP of the node in the response message
-	QueryResponse []string
-	Payload       map[uint64][]string
+	Type          string  // PING | SYNC | ACK | FIND_SUCCESSOR | CLOSEST_PRECEDING_NODE
+	Nodeid        ring.ID // ID of the node in the response message
+	IP            string  // IP of the node in the response message
+	QueryResponse *RRSet  // Result of a GET, nil if the key is not stored
+	Payload       map[ring.ID]RRSet
+	Replicas      map[ring.ID]map[ring.ID]RRSet // Replicas handed over on SHIFT, by the ID of the node they belong to
+	Successors    []Pointer                     // Successor list of the node, on GET_SUCCESSOR
+	Subtrees      []int                         // Nodes of the Merkle tree that differ, on SYNC
+	Hashes        []uint64                      // Hashes of the children of Subtrees, on SYNC above the leaves
 }
 
 /*
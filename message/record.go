@@ -0,0 +1,123 @@
+package message
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/dns"
+)
+
+/*
+All the records of one type for one name. It is the unit that is stored in the chord network, cached,
+and carried by GET, PUT, SHIFT and REPLICATE messages. The key of an RRSet in the network is the hash
+of RRSetKey(Name, Type).
+*/
+type RRSet struct {
+	Name      string   // Queried name, fully qualified and lower case.
+	Type      uint16   // Queried type. Records may contain other types, e.g. a CNAME leading to the answer.
+	Records   []dns.RR // Answer records, in the order they should be returned. TTLs are the ones originally received.
+	Expires   int64    // Unix time in seconds after which the RRSet must not be served.
+	Rcode     int      // dns.RcodeNameError for a cached NXDOMAIN. A NODATA answer is dns.RcodeSuccess with no Records.
+	Authority []dns.RR // SOA record that came with a negative answer, if any.
+	Version   Version  // Set when the RRSet is written to the network, see Newer.
+}
+
+/*
+Returns the string whose hash places the RRSet of (name, rrtype) on the ring.
+*/
+func RRSetKey(name string, rrtype uint16) string {
+	return strings.ToLower(dns.Fqdn(name)) + "/" + dns.TypeString(rrtype)
+}
+
+/*
+Creates an RRSet that expires when its shortest lived record does. ttl is used if there are no records.
+*/
+func NewRRSet(name string, rrtype uint16, records []dns.RR, ttl uint32, now time.Time) RRSet {
+	for i, rr := range records {
+		if i == 0 || rr.TTL < ttl {
+			ttl = rr.TTL
+		}
+	}
+	return RRSet{
+		Name:    strings.ToLower(dns.Fqdn(name)),
+		Type:    rrtype,
+		Records: records,
+		Expires: now.Unix() + int64(ttl),
+	}
+}
+
+/*
+Creates an RRSet that remembers that name does not exist (rcode dns.RcodeNameError) or has no records of
+type rrtype (rcode dns.RcodeSuccess). Following RFC 2308 5, it expires after the smaller of the TTL and the
+MINIMUM field of the SOA record in authority, or after ttl if there is no SOA record.
+*/
+func NewNegativeRRSet(name string, rrtype uint16, rcode int, authority []dns.RR, ttl uint32, now time.Time) RRSet {
+	for _, rr := range authority {
+		if rr.Type != dns.TypeSOA {
+			continue
+		}
+		ttl = rr.TTL
+		fields := strings.Fields(rr.Data)
+		if len(fields) == 7 {
+			if minimum, err := strconv.ParseUint(fields[6], 10, 32); err == nil && uint32(minimum) < ttl {
+				ttl = uint32(minimum)
+			}
+		}
+	}
+	return RRSet{
+		Name:      strings.ToLower(dns.Fqdn(name)),
+		Type:      rrtype,
+		Expires:   now.Unix() + int64(ttl),
+		Rcode:     rcode,
+		Authority: authority,
+	}
+}
+
+/*
+Returns true if the RRSet caches an NXDOMAIN or NODATA answer.
+*/
+func (rrset *RRSet) Negative() bool {
+	return rrset.Rcode != dns.RcodeSuccess || len(rrset.Records) == 0
+}
+
+/*
+Returns true if the RRSet is a more recent version than other, which it replaces wherever they meet: the last
+writer wins. RRSets of the same version, such as those written by older nodes, are ordered by expiry, since
+the RRSet fetched last expires last.
+*/
+func (rrset *RRSet) Newer(other RRSet) bool {
+	if rrset.Version != other.Version {
+		return rrset.Version.After(other.Version)
+	}
+	return rrset.Expires > other.Expires
+}
+
+/*
+Returns true if the RRSet can no longer be served at time now.
+*/
+func (rrset *RRSet) Expired(now time.Time) bool {
+	return now.Unix() >= rrset.Expires
+}
+
+/*
+Returns a copy of the answer and authority records with their TTL lowered to the time left until the
+RRSet expires.
+*/
+func (rrset *RRSet) Remaining(now time.Time) ([]dns.RR, []dns.RR) {
+	remaining := rrset.Expires - now.Unix()
+	if remaining < 0 {
+		remaining = 0
+	}
+	lower := func(in []dns.RR) []dns.RR {
+		out := make([]dns.RR, len(in))
+		for i, rr := range in {
+			if int64(rr.TTL) > remaining {
+				rr.TTL = uint32(remaining)
+			}
+			out[i] = rr
+		}
+		return out
+	}
+	return lower(rrset.Records), lower(rrset.Authority)
+}
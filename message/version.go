@@ -0,0 +1,69 @@
+package message
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/ring"
+)
+
+/*
+Version of an RRSet: the hybrid logical clock reading of the node that wrote it, and the ID of that node,
+which orders the versions written at the same reading. The zero Version is older than any other, and is the
+version of the RRSets written by older nodes.
+*/
+type Version struct {
+	Wall    int64   // Unix time in nanoseconds, or the largest one seen if the clock of the writer lagged behind it.
+	Logical uint32  // Orders the versions written at the same Wall.
+	Origin  ring.ID // Node that wrote the RRSet.
+}
+
+/*
+Returns true if the version was written after other. Versions of different origins written at the same
+clock reading are ordered by origin, so that every node picks the same one.
+*/
+func (v Version) After(other Version) bool {
+	if v.Wall != other.Wall {
+		return v.Wall > other.Wall
+	}
+	if v.Logical != other.Logical {
+		return v.Logical > other.Logical
+	}
+	return v.Origin.Cmp(other.Origin) > 0
+}
+
+/*
+Hybrid logical clock: its readings follow the wall clock, but are always after the previous ones and after
+every version observed, so that a write is newer than the versions it may have seen even if the clock of its
+node lags behind. The zero Clock is ready to use.
+*/
+type Clock struct {
+	mu      sync.Mutex
+	wall    int64
+	logical uint32
+}
+
+/*
+Returns a new version written by origin, given the time now of its wall clock.
+*/
+func (c *Clock) Now(now time.Time, origin ring.ID) Version {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if wall := now.UnixNano(); wall > c.wall {
+		c.wall, c.logical = wall, 0
+	} else {
+		c.logical++
+	}
+	return Version{Wall: c.wall, Logical: c.logical, Origin: origin}
+}
+
+/*
+Moves the clock past v, which was received from another node.
+*/
+func (c *Clock) Observe(v Version) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v.Wall > c.wall || v.Wall == c.wall && v.Logical > c.logical {
+		c.wall, c.logical = v.Wall, v.Logical
+	}
+}
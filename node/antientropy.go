@@ -0,0 +1,415 @@
+package node
+
+import (
+	"container/heap"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/dns"
+	"github.com/fauzxan/dns-chord/v2/merkle"
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/fauzxan/dns-chord/v2/transport"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+Replicas are kept in sync with their owner by anti-entropy rather than by resending every key. Every node
+keeps a Merkle tree of the keys it stores for each owner, see keyTree. Each round, the owner sends the root
+hash of its keys to each replica holder, which compares it with the tree of its copy. Only the subtrees whose hashes differ are compared further, level by level, and
+the entries of the leaves that still differ are exchanged: the owner takes the newer copies of its keys the
+replica holds, e.g. writes that reached it but not the owner, then sends the replica the entries it lacks and
+tells it which ones to drop. Replicas that drifted are repaired this way, and replicas in sync cost one hash.
+*/
+
+/*
+Counters of the anti-entropy rounds run by a node as the owner of its keys. Sizes are approximate: they
+count the hashes, keys and records exchanged, not the encoding overhead.
+*/
+type SyncStats struct {
+	Rounds      uint64 // Replica holders compared.
+	InSync      uint64 // Replica holders whose root hash matched.
+	Fallbacks   uint64 // Replica holders that did not know SYNC, and were sent every key.
+	KeysSent    uint64 // Entries sent to replica holders.
+	KeysDropped uint64 // Entries replica holders were told to drop.
+	KeysTaken   uint64 // Newer entries taken from replica holders.
+	BytesSent   uint64 // Bytes exchanged by the rounds, both ways.
+	BytesFull   uint64 // Bytes the same rounds would have sent by replicating every key.
+}
+
+/*
+Returns the bytes saved by anti-entropy over replicating every key, negative if it cost more.
+*/
+func (stats SyncStats) BytesSaved() int64 {
+	return int64(stats.BytesFull) - int64(stats.BytesSent)
+}
+
+/*
+Returns a copy of the anti-entropy counters of the node.
+*/
+func (node *Node) SyncStats() SyncStats {
+	node.syncMu.Lock()
+	defer node.syncMu.Unlock()
+	return node.syncStats
+}
+
+func (node *Node) countSync(count func(stats *SyncStats)) {
+	node.syncMu.Lock()
+	count(&node.syncStats)
+	node.syncMu.Unlock()
+}
+
+/*
+Brings the replica of our keys held by holder in sync with them, given the Merkle tree of our keys and the
+size of the keys it was built from.
+*/
+func (node *Node) syncWith(holder Pointer, tree *merkle.Tree, full uint64) {
+	level, subtrees, hashes := 0, []int{0}, []uint64{tree.Root()}
+	var sent uint64
+	defer func() {
+		node.countSync(func(stats *SyncStats) {
+			stats.Rounds++
+			stats.BytesSent += sent
+			stats.BytesFull += full
+		})
+	}()
+	for {
+		msg := message.RequestMessage{Type: SYNC, TargetId: node.Nodeid, Level: level, Subtrees: subtrees, Hashes: hashes}
+		reply, err := node.CallRPC(msg, holder.IP)
+		var remote *transport.RemoteError
+		if errors.As(err, &remote) {
+			// Nodes of earlier releases only know REPLICATE
+			node.countSync(func(stats *SyncStats) { stats.Fallbacks++ })
+			node.CallRPC(message.RequestMessage{Type: REPLICATE, TargetId: node.Nodeid, Payload: node.storageCopy(node.Nodeid)}, holder.IP)
+			sent = full
+			return
+		} else if err != nil {
+			return
+		}
+		sent += hashesSize(subtrees, hashes) + hashesSize(reply.Subtrees, reply.Hashes) + payloadSize(reply.Payload)
+		switch {
+		case len(reply.Subtrees) == 0 && level == 0:
+			node.countSync(func(stats *SyncStats) { stats.InSync++ })
+			return
+		case len(reply.Subtrees) == 0:
+			// Changed while we compared, which the next round catches up with
+			return
+		case level == merkle.Depth:
+			sent += node.reconcile(holder, reply.Subtrees, reply.Payload)
+			return
+		case len(reply.Hashes) != len(reply.Subtrees)*merkle.Fanout:
+			log.Warn().Msgf("Invalid SYNC reply from %s: %d hashes for %d subtrees", holder.IP, len(reply.Hashes), len(reply.Subtrees))
+			return
+		}
+		subtrees, hashes = nil, nil
+		for k, i := range reply.Subtrees {
+			if !merkle.Valid(level, i) {
+				log.Warn().Msgf("Invalid SYNC reply from %s: no subtree %d at level %d", holder.IP, i, level)
+				return
+			}
+			theirs := reply.Hashes[k*merkle.Fanout : (k+1)*merkle.Fanout]
+			for c, hash := range tree.Children(level, i) {
+				if hash != theirs[c] {
+					subtrees = append(subtrees, i*merkle.Fanout+c)
+					hashes = append(hashes, hash)
+				}
+			}
+		}
+		if len(subtrees) == 0 {
+			return
+		}
+		level++
+	}
+}
+
+/*
+Reconciles the leaves of our keys that differ from the replica held by holder, given its entries in them.
+We take the newer copies of our keys, then send it the entries it lacks or holds an older version of, and
+tell it to drop the ones we do not have. Returns the bytes sent.
+*/
+func (node *Node) reconcile(holder Pointer, leaves []int, theirs map[ring.ID]message.RRSet) uint64 {
+	inLeaves := make(map[int]bool, len(leaves))
+	for _, leaf := range leaves {
+		inLeaves[leaf] = true
+	}
+	predecessor := node.predecessor()
+	now := node.now()
+	payload := make(map[ring.ID]message.RRSet)
+	drop := make(map[ring.ID]message.Version)
+	taken := 0
+
+	node.storageLock().Lock()
+	if node.HashIPStorage == nil {
+		node.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet)
+	}
+	if node.HashIPStorage[node.Nodeid] == nil {
+		node.HashIPStorage[node.Nodeid] = make(map[ring.ID]message.RRSet)
+	}
+	ours := node.HashIPStorage[node.Nodeid]
+	for key, rrset := range theirs {
+		// Only keys in our range, others are stale copies of keys handed off since
+		if (predecessor == Pointer{}) || !belongsTo(key, predecessor.Nodeid, node.Nodeid) || rrset.Expired(now) {
+			continue
+		}
+		if current, ok := ours[key]; !ok || current.Expired(now) || rrset.Newer(current) {
+			node.store(node.Nodeid, key, rrset)
+			taken++
+		}
+	}
+	for key, rrset := range ours {
+		if !inLeaves[merkle.Leaf(key)] || rrset.Expired(now) {
+			continue
+		}
+		if replica, ok := theirs[key]; !ok || replica.Version != rrset.Version || replica.Expires != rrset.Expires {
+			payload[key] = rrset
+		}
+	}
+	for key, rrset := range theirs {
+		if current, ok := ours[key]; !ok || current.Expired(now) {
+			drop[key] = rrset.Version
+		}
+	}
+	node.storageLock().Unlock()
+
+	node.countSync(func(stats *SyncStats) {
+		stats.KeysSent += uint64(len(payload))
+		stats.KeysDropped += uint64(len(drop))
+		stats.KeysTaken += uint64(taken)
+	})
+	if taken > 0 {
+		log.Info().Msgf("Took %d newer keys from the replica held by %s", taken, holder.IP)
+	}
+	if len(payload) == 0 && len(drop) == 0 {
+		return 0
+	}
+	// If holder went down since it answered, the entries it lacks are kept as hints
+	node.callOrHint(message.RequestMessage{Type: REPLICATE, TargetId: node.Nodeid, Payload: payload, Drop: drop}, holder)
+	return payloadSize(payload) + uint64(len(drop)*(ringIDSize+versionSize))
+}
+
+/*
+Answers a SYNC from owner, which compares the given hashes of the subtrees at level of the Merkle tree of its
+keys with the tree of our replica of them. Returns the subtrees that differ, and the hashes of their children
+or, at the leaves, our entries in them.
+*/
+func (node *Node) processSync(owner ring.ID, level int, subtrees []int, hashes []uint64) ([]int, []uint64, map[ring.ID]message.RRSet, error) {
+	if len(subtrees) != len(hashes) {
+		return nil, nil, nil, fmt.Errorf("%d hashes for %d subtrees", len(hashes), len(subtrees))
+	}
+	for _, i := range subtrees {
+		if !merkle.Valid(level, i) {
+			return nil, nil, nil, fmt.Errorf("no subtree %d at level %d", i, level)
+		}
+	}
+	now := node.now()
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
+	if level == 0 {
+		node.expireKeys(owner, now)
+	}
+	tree := node.keyTree(owner)
+	var differ []int
+	var children []uint64
+	for k, i := range subtrees {
+		if tree.Hash(level, i) == hashes[k] {
+			continue
+		}
+		differ = append(differ, i)
+		if level < merkle.Depth {
+			children = append(children, tree.Children(level, i)...)
+		}
+	}
+	if level < merkle.Depth || len(differ) == 0 {
+		return differ, children, nil, nil
+	}
+	inLeaves := make(map[int]bool, len(differ))
+	for _, leaf := range differ {
+		inLeaves[leaf] = true
+	}
+	entries := make(map[ring.ID]message.RRSet)
+	for key, rrset := range node.HashIPStorage[owner] {
+		if inLeaves[merkle.Leaf(key)] && !rrset.Expired(now) {
+			entries[key] = rrset
+		}
+	}
+	return differ, nil, entries, nil
+}
+
+/*
+Merkle tree of the entries stored for an owner, kept up to date as they are stored and removed (see store and
+unstore) so that anti-entropy does not scan the storage. Both sides of a round first remove the entries that
+expired (see expireKeys), so that their trees do not differ only because one of them was swept earlier.
+*/
+type keyTree struct {
+	*merkle.Tree
+	size     uint64      // Of the entries, see entrySize.
+	expiries expiryQueue // When the entries expire, including replaced ones, which are skipped.
+}
+
+/*
+Min-heap of the expiry of entries, see container/heap.
+*/
+type expiryQueue []expiry
+
+type expiry struct {
+	expires int64 // As in RRSet.Expires.
+	key     ring.ID
+}
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].expires < q[j].expires }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *expiryQueue) Push(x any)        { *q = append(*q, x.(expiry)) }
+func (q *expiryQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
+
+/*
+Removes the expired entries stored for owner, in O(log n) each. Must be called with the storage lock held for
+writing.
+*/
+func (node *Node) expireKeys(owner ring.ID, now time.Time) {
+	t := node.keyTree(owner)
+	for len(t.expiries) > 0 && t.expiries[0].expires <= now.Unix() {
+		next := heap.Pop(&t.expiries).(expiry)
+		if rrset, ok := node.HashIPStorage[owner][next.key]; ok && rrset.Expired(now) {
+			node.unstore(owner, next.key)
+		}
+	}
+}
+
+/*
+Returns the tree of the entries stored for owner, which is built the first time, e.g. after the storage was
+read from disk. Must be called with the storage lock held for writing.
+*/
+func (node *Node) keyTree(owner ring.ID) *keyTree {
+	if t, ok := node.trees[owner]; ok {
+		return t
+	}
+	t := &keyTree{Tree: merkle.New()}
+	storage, ok := node.HashIPStorage[owner]
+	if !ok {
+		// Not kept, so that owners we hold nothing for do not accumulate
+		return t
+	}
+	for key, rrset := range storage {
+		t.Toggle(key, entryHash(key, rrset))
+		t.size += entrySize(rrset)
+		t.expiries = append(t.expiries, expiry{rrset.Expires, key})
+	}
+	heap.Init(&t.expiries)
+	if node.trees == nil {
+		node.trees = make(map[ring.ID]*keyTree)
+	}
+	node.trees[owner] = t
+	return t
+}
+
+/*
+Adds the entry stored under key for owner to its tree, or removes it if added is false. Trees that were not
+built yet are left alone, as they are built from the storage. Must be called with the storage lock held for
+writing.
+*/
+func (node *Node) track(owner, key ring.ID, rrset message.RRSet, added bool) {
+	t, ok := node.trees[owner]
+	if !ok {
+		return
+	}
+	t.Toggle(key, entryHash(key, rrset))
+	if added {
+		t.size += entrySize(rrset)
+		heap.Push(&t.expiries, expiry{rrset.Expires, key})
+		if storage := node.HashIPStorage[owner]; len(t.expiries) > 2*len(storage)+64 {
+			// Mostly entries replaced or removed since, which are left out
+			t.expiries = t.expiries[:0]
+			for key, rrset := range storage {
+				t.expiries = append(t.expiries, expiry{rrset.Expires, key})
+			}
+			heap.Init(&t.expiries)
+		}
+	} else {
+		t.size -= entrySize(rrset)
+	}
+}
+
+/*
+Returns a copy of the Merkle tree of the unexpired keys stored for the node with the given ID, and their size.
+*/
+func (node *Node) merkleTree(id ring.ID) (*merkle.Tree, uint64) {
+	now := node.now()
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
+	node.expireKeys(id, now)
+	t := node.keyTree(id)
+	return t.Clone(), t.size
+}
+
+/*
+Returns an error if the Merkle tree of the keys stored for some node does not match them. Used by simulations
+to check that every change to the storage is tracked.
+*/
+func (node *Node) VerifyTrees() error {
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
+	for owner, t := range node.trees {
+		rebuilt := merkle.New()
+		var size uint64
+		for key, rrset := range node.HashIPStorage[owner] {
+			rebuilt.Toggle(key, entryHash(key, rrset))
+			size += entrySize(rrset)
+		}
+		if rebuilt.Root() != t.Root() || size != t.size {
+			return fmt.Errorf("Merkle tree of the keys of %s does not match them", owner)
+		}
+	}
+	return nil
+}
+
+/*
+Returns the hash of a stored entry. Two copies of an entry hash the same if they have the same version and
+expiry, which tells them apart without comparing their records.
+*/
+func entryHash(key ring.ID, rrset message.RRSet) uint64 {
+	var b [2*ringIDSize + 8 + 4 + 8]byte
+	copy(b[:], key[:])
+	binary.BigEndian.PutUint64(b[ringIDSize:], uint64(rrset.Version.Wall))
+	binary.BigEndian.PutUint32(b[ringIDSize+8:], rrset.Version.Logical)
+	copy(b[ringIDSize+12:], rrset.Version.Origin[:])
+	binary.BigEndian.PutUint64(b[2*ringIDSize+12:], uint64(rrset.Expires))
+	sum := sha256.Sum256(b[:])
+	return binary.BigEndian.Uint64(sum[:])
+}
+
+const (
+	ringIDSize  = len(ring.ID{})
+	versionSize = 8 + 4 + ringIDSize
+)
+
+// Approximate size of a stored entry on the wire, with its key.
+func entrySize(rrset message.RRSet) uint64 {
+	size := ringIDSize + len(rrset.Name) + 2 + 8 + 4 + versionSize
+	for _, records := range [][]dns.RR{rrset.Records, rrset.Authority} {
+		for _, rr := range records {
+			size += len(rr.Name) + len(rr.Data) + 2 + 2 + 4
+		}
+	}
+	return uint64(size)
+}
+
+func payloadSize(payload map[ring.ID]message.RRSet) uint64 {
+	var size uint64
+	for _, rrset := range payload {
+		size += entrySize(rrset)
+	}
+	return size
+}
+
+func hashesSize(subtrees []int, hashes []uint64) uint64 {
+	return uint64(4*len(subtrees) + 8*len(hashes))
+}
@@ -0,0 +1,200 @@
+package node
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+Writes sent to replica holders that cannot be reached are kept as hints by the node that sent them, and
+delivered once the holder answers a PING again, so that a short outage does not leave it without the writes
+made meanwhile. Hints are kept in memory, at most MaxHints of them for HintTTL. The writes whose hints were
+dropped, expired or lost in a restart are still caught up with by anti-entropy, only later.
+*/
+
+type hint struct {
+	owner   ring.ID // Node whose keys the RRSet belongs to, as in the PUT it was sent in.
+	rrset   message.RRSet
+	expires time.Time
+}
+
+/*
+Counters of the hints kept by a node.
+*/
+type HintStats struct {
+	Stored    uint64 // Writes kept as hints, including those replacing an older hint for the same key.
+	Delivered uint64 // Hints delivered to their target.
+	Dropped   uint64 // Writes not kept because MaxHints were pending.
+	Expired   uint64 // Hints dropped after HintTTL, or when their RRSet expired, without being delivered.
+}
+
+/*
+Returns a copy of the hint counters of the node.
+*/
+func (node *Node) HintStats() HintStats {
+	node.hintMu.Lock()
+	defer node.hintMu.Unlock()
+	return node.hintStats
+}
+
+/*
+Returns the number of hints waiting for delivery.
+*/
+func (node *Node) PendingHints() int {
+	node.hintMu.Lock()
+	defer node.hintMu.Unlock()
+	return node.hintCount
+}
+
+/*
+Keeps the writes in payload, which failed to reach target, as hints for it. A hint for a key target already
+has one for is replaced if the write is newer.
+*/
+func (node *Node) addHint(target Pointer, owner ring.ID, payload map[ring.ID]message.RRSet) {
+	expires := node.now().Add(node.hintTTL())
+	node.hintMu.Lock()
+	defer node.hintMu.Unlock()
+	if node.hints == nil {
+		node.hints = make(map[Pointer]map[ring.ID]hint)
+	}
+	if node.hints[target] == nil {
+		node.hints[target] = make(map[ring.ID]hint)
+	}
+	for key, rrset := range payload {
+		if pending, ok := node.hints[target][key]; ok {
+			if rrset.Newer(pending.rrset) {
+				node.hints[target][key] = hint{owner, rrset, expires}
+				node.hintStats.Stored++
+			}
+			continue
+		}
+		if node.hintCount >= node.maxHints() {
+			node.hintStats.Dropped++
+			continue
+		}
+		node.hints[target][key] = hint{owner, rrset, expires}
+		node.hintCount++
+		node.hintStats.Stored++
+	}
+	if len(node.hints[target]) == 0 {
+		delete(node.hints, target)
+	}
+}
+
+/*
+Puts back hints for target that could not be delivered, unless newer ones were added for their keys since.
+*/
+func (node *Node) restoreHints(target Pointer, hints map[ring.ID]hint) {
+	node.hintMu.Lock()
+	defer node.hintMu.Unlock()
+	if node.hints == nil {
+		node.hints = make(map[Pointer]map[ring.ID]hint)
+	}
+	if node.hints[target] == nil {
+		node.hints[target] = make(map[ring.ID]hint)
+	}
+	for key, hint := range hints {
+		if pending, ok := node.hints[target][key]; !ok {
+			node.hints[target][key] = hint
+			node.hintCount++
+		} else if hint.rrset.Newer(pending.rrset) {
+			node.hints[target][key] = hint
+		}
+	}
+}
+
+/*
+Same as CallRPC for a write (PUT or REPLICATE) to a replica holder, whose payload is kept as hints if the
+holder cannot be reached.
+*/
+func (node *Node) callOrHint(msg message.RequestMessage, target Pointer) (message.ResponseMessage, error) {
+	reply, err := node.CallRPC(msg, target.IP)
+	if errors.Is(err, ErrPeerUnreachable) {
+		node.addHint(target, msg.TargetId, msg.Payload)
+	}
+	return reply, err
+}
+
+/*
+Delivers the pending hints periodically.
+*/
+func (node *Node) deliverHints() {
+	for node.wait(5 * time.Second) {
+		node.deliverHintsOnce()
+	}
+}
+
+/*
+Drops the expired hints, then PINGs the target of each remaining one and delivers its hints if it answers.
+Hints that cannot be delivered are kept for the next run.
+*/
+func (node *Node) deliverHintsOnce() {
+	now := node.now()
+	node.hintMu.Lock()
+	targets := make([]Pointer, 0, len(node.hints))
+	for target, hints := range node.hints {
+		for key, hint := range hints {
+			if now.After(hint.expires) || hint.rrset.Expired(now) {
+				delete(hints, key)
+				node.hintCount--
+				node.hintStats.Expired++
+			}
+		}
+		if len(hints) == 0 {
+			delete(node.hints, target)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	node.hintMu.Unlock()
+	// Sorted, so that simulations send the same messages on every run
+	sort.Slice(targets, func(a, b int) bool { return targets[a].Nodeid.Cmp(targets[b].Nodeid) < 0 })
+
+	for _, target := range targets {
+		if _, err := node.CallRPC(message.RequestMessage{Type: PING}, target.IP); err != nil {
+			continue
+		}
+		node.hintMu.Lock()
+		hints := node.hints[target]
+		delete(node.hints, target)
+		node.hintCount -= len(hints)
+		node.hintMu.Unlock()
+
+		byOwner := make(map[ring.ID]map[ring.ID]hint)
+		for key, pending := range hints {
+			if byOwner[pending.owner] == nil {
+				byOwner[pending.owner] = make(map[ring.ID]hint)
+			}
+			byOwner[pending.owner][key] = pending
+		}
+		owners := make([]ring.ID, 0, len(byOwner))
+		for owner := range byOwner {
+			owners = append(owners, owner)
+		}
+		sort.Slice(owners, func(a, b int) bool { return owners[a].Cmp(owners[b]) < 0 })
+		delivered := 0
+		for _, owner := range owners {
+			payload := make(map[ring.ID]message.RRSet, len(byOwner[owner]))
+			for key, hint := range byOwner[owner] {
+				payload[key] = hint.rrset
+			}
+			reply, err := node.CallRPC(message.RequestMessage{Type: PUT, TargetId: owner, Payload: payload}, target.IP)
+			if err != nil || reply.Type != ACK {
+				node.restoreHints(target, byOwner[owner])
+				continue
+			}
+			delivered += len(payload)
+		}
+		node.hintMu.Lock()
+		node.hintStats.Delivered += uint64(delivered)
+		node.hintMu.Unlock()
+		if delivered > 0 {
+			log.Info().Msgf("Delivered %d hinted writes to %s", delivered, target.IP)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+A process hosting several virtual nodes, so that it owns several shares of the ring instead of one. With a
+handful of processes, a single ID each leaves some of them owning most of the keys; more virtual nodes even it
+out, and a process given more of them (through its weight) takes a larger share.
+
+Each virtual node has its own ID, finger table, successors and predecessor, and runs its own periodic tasks.
+They share the RPC listener and transport of the process, its query cache and upstream resolvers, and its
+storage: HashIPStorage is keyed by the ID of the node owning the keys, so the keys of each virtual node stay
+apart in it. Virtual node k is at VirtualAddress(addr, k), and its ID is the hash of that address.
+
+Virtual nodes of a process fail together, so replicas are only placed on other processes.
+*/
+type Host struct {
+	Nodes []*Node // Virtual nodes, by index. Nodes[0] is the one at the address of the process.
+}
+
+/*
+Creates a host of n virtual nodes. primary becomes Nodes[0], and the others are configured like it. It must
+not be used before.
+*/
+func NewHost(primary *Node, n int) *Host {
+	if primary.HashIPStorage == nil {
+		primary.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet)
+	}
+	if primary.trees == nil {
+		primary.trees = make(map[ring.ID]*keyTree)
+	}
+	host := &Host{Nodes: []*Node{primary}}
+	primary.host = host
+	for k := 1; k < n; k++ {
+		addr := VirtualAddress(primary.IP, k)
+		host.Nodes = append(host.Nodes, &Node{
+			Nodeid:            ring.Hash(addr, primary.idBits()),
+			IP:                addr,
+			CachedQuery:       primary.queryCache(),
+			HashIPStorage:     primary.HashIPStorage,
+			trees:             primary.trees,
+			Upstream:          primary.Upstream,
+			DataDir:           primary.DataDir,
+			ReplicationFactor: primary.ReplicationFactor,
+			SuccListLength:    primary.SuccListLength,
+			ReadQuorum:        primary.ReadQuorum,
+			WriteQuorum:       primary.WriteQuorum,
+			ReadRepair:        primary.ReadRepair,
+			MaxHints:          primary.MaxHints,
+			HintTTL:           primary.HintTTL,
+			IDBits:            primary.IDBits,
+			RPCTimeout:        primary.RPCTimeout,
+			Transport:         primary.transportLayer(),
+			Now:               primary.Now,
+			Manual:            primary.Manual,
+			storageMu:         primary.storageLock(),
+			host:              host,
+		})
+	}
+	return host
+}
+
+/*
+Passes a message to the virtual node it was sent to. Messages without a virtual node in their receiver, e.g.
+from nodes that do not set it, go to Nodes[0].
+*/
+func (host *Host) HandleIncomingMessage(msg *message.RequestMessage, reply *message.ResponseMessage) error {
+	_, k := splitVirtual(msg.Receiver)
+	if k >= len(host.Nodes) {
+		return fmt.Errorf("no virtual node %d at %s", k, host.Nodes[0].IP)
+	}
+	return host.Nodes[k].HandleIncomingMessage(msg, reply)
+}
+
+/*
+Serves the messages sent to every virtual node at addr, in the background.
+*/
+func (host *Host) Listen(addr string) error {
+	return host.Nodes[0].transportLayer().Listen(addr, host)
+}
+
+/*
+Creates a new network with Nodes[0], which the other virtual nodes join. The host must be listening.
+*/
+func (host *Host) CreateNetwork() error {
+	host.Nodes[0].CreateNetwork()
+	return host.JoinNetwork(host.Nodes[0].IP)
+}
+
+/*
+Joins the network through helper with every virtual node that is not part of it yet, so that a failed join
+can be retried through another helper.
+*/
+func (host *Host) JoinNetwork(helper string) error {
+	for _, node := range host.Nodes {
+		if node.joined() {
+			continue
+		}
+		if err := node.JoinNetwork(helper); err != nil {
+			return fmt.Errorf("virtual node %s: %w", node.IP, err)
+		}
+	}
+	host.stabilize()
+	return nil
+}
+
+/*
+Stabilizes every virtual node once per virtual node, so that they know each other before the host serves
+queries. The periodic tasks would take as many seconds to get there, and keys stored in the meantime could end
+up on the wrong virtual node.
+*/
+func (host *Host) stabilize() {
+	if len(host.Nodes) == 1 {
+		return
+	}
+	for range host.Nodes {
+		for _, node := range host.Nodes {
+			node.stabilizeOnce()
+		}
+	}
+}
+
+/*
+Makes every virtual node leave the network, each handing off its keys like a single node does, then drops the
+replicas held for other nodes and closes the transport.
+*/
+func (host *Host) Leave() error {
+	var errs []error
+	for _, node := range host.Nodes {
+		if err := node.Leave(); err != nil && !errors.Is(err, ErrNotJoined) {
+			errs = append(errs, fmt.Errorf("virtual node %s: %w", node.IP, err))
+		}
+	}
+	primary := host.Nodes[0]
+	primary.storageLock().Lock()
+	for id := range primary.HashIPStorage {
+		if !primary.hosted(id) {
+			primary.unstoreAll(id)
+		}
+	}
+	primary.storageLock().Unlock()
+	primary.writeToStorage()
+	primary.transportLayer().Close()
+	log.Info().Msgf("> %d virtual nodes left the network", len(host.Nodes))
+	return errors.Join(errs...)
+}
+
+/*
+Returns true if id is the ID of one of the virtual nodes of the process of node.
+*/
+func (node *Node) hosted(id ring.ID) bool {
+	if node.host == nil {
+		return id == node.Nodeid
+	}
+	for _, n := range node.host.Nodes {
+		if n.Nodeid == id {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns true if pointer is a virtual node of the process of node that left the network, e.g. while the host
+leaves. Messages sent to it would be refused.
+*/
+func (node *Node) departed(pointer Pointer) bool {
+	if node.host == nil {
+		return false
+	}
+	for _, n := range node.host.Nodes {
+		if n.IP == pointer.IP {
+			return n != node && n.hasLeft()
+		}
+	}
+	return false
+}
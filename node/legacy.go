@@ -0,0 +1,176 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/fauzxan/dns-chord/v2/dns"
+)
+
+/*
+***************************************************
+		LEGACY DNS LOOKUPS
+***************************************************
+*/
+
+/*
+Looks up the records of the given type through the upstream resolvers, or through the host's resolver if
+none are configured. Returns ErrNameNotFound if the name does not exist. authority holds the SOA record
+of negative answers, when the upstream provides one.
+*/
+func (node *Node) lookupLegacy(name string, rrtype uint16) (records []dns.RR, authority []dns.RR, err error) {
+	if node.Upstream == nil {
+		records, err = lookupHost(name, rrtype)
+		return records, nil, err
+	}
+	resp, err := node.Upstream.Query(name, rrtype)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, rr := range resp.Authority {
+		if rr.Type == dns.TypeSOA {
+			authority = append(authority, rr)
+		}
+	}
+	if resp.Rcode == dns.RcodeNameError {
+		return nil, authority, ErrNameNotFound
+	}
+	for _, rr := range resp.Answers {
+		if rr.Class == dns.ClassINET {
+			records = append(records, rr)
+		}
+	}
+	return records, authority, nil
+}
+
+/*
+Looks up the records of the given type through the host's resolver. The net package does not expose
+TTLs, so every record gets DEFAULT_TTL. Returns ErrNameNotFound if the name does not exist, and no records
+if the name exists but has no records of that type. The host's resolver reports both as not found, so a
+name is only taken not to exist if it has no address of either family either.
+*/
+func lookupHost(name string, rrtype uint16) ([]dns.RR, error) {
+	ctx := context.Background()
+	resolver := net.DefaultResolver
+	owner := dns.Fqdn(name)
+	record := func(rrtype uint16, data string) dns.RR {
+		return dns.RR{Name: owner, Type: rrtype, Class: dns.ClassINET, TTL: DEFAULT_TTL, Data: data}
+	}
+	var records []dns.RR
+	var err error
+	switch rrtype {
+	case dns.TypeA, dns.TypeAAAA:
+		// Both families are looked up, so that a name with addresses of the other family only is not
+		// reported as missing.
+		var ips []net.IP
+		ips, err = resolver.LookupIP(ctx, "ip", name)
+		if isNotFound(err) {
+			return nil, ErrNameNotFound
+		}
+		for _, ip := range ips {
+			if (ip.To4() != nil) == (rrtype == dns.TypeA) {
+				records = append(records, record(rrtype, ip.String()))
+			}
+		}
+	case dns.TypeCNAME:
+		var cname string
+		cname, err = resolver.LookupCNAME(ctx, name)
+		if err == nil && !strings.EqualFold(cname, owner) {
+			records = append(records, record(rrtype, cname))
+		}
+	case dns.TypeMX:
+		var mxs []*net.MX
+		mxs, err = resolver.LookupMX(ctx, name)
+		for _, mx := range mxs {
+			records = append(records, record(rrtype, fmt.Sprintf("%d %s", mx.Pref, dns.Fqdn(mx.Host))))
+		}
+	case dns.TypeTXT:
+		var txts []string
+		txts, err = resolver.LookupTXT(ctx, name)
+		for _, txt := range txts {
+			records = append(records, record(rrtype, dns.TXTData(txt)))
+		}
+	case dns.TypeNS:
+		var nss []*net.NS
+		nss, err = resolver.LookupNS(ctx, name)
+		for _, ns := range nss {
+			records = append(records, record(rrtype, dns.Fqdn(ns.Host)))
+		}
+	case dns.TypeSRV:
+		var srvs []*net.SRV
+		_, srvs, err = resolver.LookupSRV(ctx, "", "", name)
+		for _, srv := range srvs {
+			records = append(records, record(rrtype, fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, dns.Fqdn(srv.Target))))
+		}
+	case dns.TypePTR:
+		addr, ok := reverseToIP(name)
+		if !ok {
+			return nil, nil
+		}
+		var hosts []string
+		hosts, err = resolver.LookupAddr(ctx, addr)
+		for _, host := range hosts {
+			records = append(records, record(rrtype, dns.Fqdn(host)))
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, dns.TypeString(rrtype))
+	}
+
+	if isNotFound(err) {
+		// NXDOMAIN or NODATA: the name is only taken not to exist if it has no address either.
+		if _, err := resolver.LookupHost(ctx, name); isNotFound(err) {
+			return nil, ErrNameNotFound
+		}
+		return nil, nil
+	}
+	return records, err
+}
+
+/*
+Returns true if err is the host's resolver reporting that a name has no records of the type looked up,
+possibly because it does not exist.
+*/
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+/*
+Turns a reverse lookup name such as "4.3.2.1.in-addr.arpa" into the address "1.2.3.4".
+*/
+func reverseToIP(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if rest, ok := strings.CutSuffix(name, ".in-addr.arpa"); ok {
+		octets := strings.Split(rest, ".")
+		if len(octets) != 4 {
+			return "", false
+		}
+		for i, j := 0, len(octets)-1; i < j; i, j = i+1, j-1 {
+			octets[i], octets[j] = octets[j], octets[i]
+		}
+		ip := net.ParseIP(strings.Join(octets, "."))
+		return ip.String(), ip != nil
+	}
+	if rest, ok := strings.CutSuffix(name, ".ip6.arpa"); ok {
+		nibbles := strings.Split(rest, ".")
+		if len(nibbles) != 32 {
+			return "", false
+		}
+		ip := make(net.IP, net.IPv6len)
+		for i, nibble := range nibbles {
+			n, err := strconv.ParseUint(nibble, 16, 4)
+			if err != nil || len(nibble) != 1 {
+				return "", false
+			}
+			// The least significant nibble comes first.
+			pos := 31 - i
+			ip[pos/2] |= byte(n) << (4 * (1 - pos%2))
+		}
+		return ip.String(), true
+	}
+	return "", false
+}
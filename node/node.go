@@ -10,12 +10,18 @@ like finding successors and notifying or updating neighboring nodes.
 package node
 
 import (
-	"math"
+	"errors"
+	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/fauzxan/dns-chord/v2/cache"
+	"github.com/fauzxan/dns-chord/v2/dns"
 	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/fauzxan/dns-chord/v2/transport"
 	"github.com/rs/zerolog/log"
 )
 
@@ -24,88 +30,169 @@ var system = color.New(color.FgHiGreen).Add(color.BgBlack)
 var systemcommsin = color.New(color.FgHiMagenta).Add(color.BgBlack)
 var systemcommsout = color.New(color.FgHiYellow).Add(color.BgBlack)
 
-// Mutex to prevent race condition when accessing SuccList
-var mu sync.Mutex
-
-type Pointer struct {
-	Nodeid uint64 // ID of the pointed Node
-	IP     string // IP of the pointed Node
-}
+// A node of the ring, see message.Pointer.
+type Pointer = message.Pointer
 
 /*
 Represents everything that a node in the chord network needs to take care of.
+
+The node is accessed concurrently by the periodic tasks, the RPC handlers, the DNS server and the menu. Once
+it has created or joined a network, its ring state and storage must only be accessed through its methods,
+which hold ringMu and storageMu. RPCs are never made while holding them.
 */
 type Node struct {
-	Nodeid        uint64                         // ID of the node
-	IP            string                         // localhost or IP address AND port number. Can be set through environment variables.
-	FingerTable   []Pointer                      // id mapping to ip address
-	Successor     Pointer                        // Nodeid of it's direct successor.
-	Predecessor   Pointer                        // Nodeid of it's direct predecessor.
-	CachedQuery   map[uint64]LRUCache            // caching queries on the node locally
-	HashIPStorage map[uint64]map[uint64][]string // storage for hashed ips associated with the node
-	CacheTime     uint64                         // To keep track of scalar timestamp to assign to LRUCache
-	SuccList      []Pointer                      // Maintain a list of successors for fault tolerance
+	Nodeid        ring.ID                               // ID of the node
+	IP            string                                // Advertised address: hostname or IP address AND port number. Can be set through the configuration.
+	FingerTable   []Pointer                             // id mapping to ip address. Guarded by ringMu.
+	Successor     Pointer                               // Nodeid of it's direct successor. Guarded by ringMu.
+	Predecessor   Pointer                               // Nodeid of it's direct predecessor. Guarded by ringMu.
+	CachedQuery   *cache.LRU[ring.ID, CacheEntry]       // caching queries on the node locally. Safe for concurrent use.
+	HashIPStorage map[ring.ID]map[ring.ID]message.RRSet // storage for hashed RRSets associated with the node, by the ID of their owner. Guarded by storageMu.
+	SuccList      []Pointer                             // The next SuccListLength nodes of the ring, starting with Successor, for fault tolerance. Guarded by ringMu.
+	Upstream      *dns.Upstream                         // Resolvers queried when the chord network misses. The host's resolver is used if nil.
+
+	DataDir           string              // Directory where the storage is persisted. DEFAULT_DATA_DIR if empty.
+	ReplicationFactor int                 // Number of successors that hold a replica of the node's keys. DEFAULT_REPLICATION_FACTOR if 0.
+	SuccListLength    int                 // Length of SuccList, which bounds the replicas and the successor failures survived. DEFAULT_SUCC_LIST_LENGTH if 0.
+	ReadQuorum        int                 // Replicas consulted by reads, see QuorumGet. DEFAULT_READ_QUORUM if 0.
+	WriteQuorum       int                 // Replicas that must acknowledge writes, see QuorumPut. DEFAULT_WRITE_QUORUM if 0.
+	ReadRepair        bool                // Reads consult every replica rather than ReadQuorum of them, and repair the stale ones. See QuorumGet.
+	MaxHints          int                 // Writes kept for replica holders that cannot be reached, see addHint. DEFAULT_MAX_HINTS if 0.
+	HintTTL           time.Duration       // How long writes are kept for replica holders that cannot be reached. DEFAULT_HINT_TTL if 0.
+	IDBits            int                 // Width of node IDs and keys, the same on every node of the network. DEFAULT_ID_BITS if 0.
+	RPCTimeout        time.Duration       // Deadline of each message sent to another node. DEFAULT_RPC_TIMEOUT if 0.
+	Transport         transport.Transport // How messages are exchanged with other nodes. gRPC if nil.
+	Now               func() time.Time    // Current time, used for record expiry. time.Now if nil.
+	Manual            bool                // Do not start the periodic tasks, the caller runs them with Tick, and send messages one at a time. Used by simulations.
+
+	left          chan struct{} // Closed when the node leaves the network, which stops the periodic tasks. Guarded by ringMu.
+	adopt         bool          // The predecessor failed, see checkPredecessorOnce. Guarded by ringMu.
+	nextFinger    int           // Finger refreshed last, see fixFingersOnce. Guarded by ringMu.
+	skipped       []Pointer     // Successors that did not answer, closest first, see retrySkipped. Guarded by ringMu.
+	skippedAt     time.Time     // When skipped was set. Guarded by ringMu.
+	clock         message.Clock // Versions the RRSets written through the node, see QuorumPut.
+	syncStats     SyncStats     // Guarded by syncMu.
+	repairStats   RepairStats   // Guarded by syncMu.
+	syncMu        sync.Mutex
+	ringMu        sync.RWMutex
+	storageMu     *sync.RWMutex // Taken after ringMu when both are needed. Shared with the other virtual nodes of host.
+	host          *Host         // Process the node is a virtual node of, if any.
+	storageOnce   sync.Once     // Creates storageMu if it was not given.
+	cacheOnce     sync.Once     // Creates CachedQuery if it was not given.
+	transportOnce sync.Once     // Creates Transport if it was not given.
+
+	trees map[ring.ID]*keyTree // Merkle trees of HashIPStorage, by owner, see keyTree. Shared like it. Guarded by storageMu.
+
+	hints     map[Pointer]map[ring.ID]hint // Writes to deliver, by target and key. Guarded by hintMu.
+	hintCount int                          // Hints in hints. Guarded by hintMu.
+	hintStats HintStats                    // Guarded by hintMu.
+	hintMu    sync.Mutex
 }
 
 // Constants
 const (
-	M                  = 32
-	CACHE_SIZE         = 5
-	REPLICATION_FACTOR = 2
+	DEFAULT_ID_BITS            = 160  // Width of node IDs and keys, which is also the number of fingers.
+	DEFAULT_CACHE_SIZE         = 1024 // Entries in the query cache, unless configured otherwise.
+	DEFAULT_REPLICATION_FACTOR = 2
+	DEFAULT_SUCC_LIST_LENGTH   = 4
+	DEFAULT_READ_QUORUM        = 2 // With the default replication factor, reads see the last successful write.
+	DEFAULT_WRITE_QUORUM       = 2
+	DEFAULT_MAX_HINTS          = 1024
+	DEFAULT_HINT_TTL           = 10 * time.Minute
+	DEFAULT_DATA_DIR           = "./data"
+	DEFAULT_TTL                = 300 // TTL in seconds given to records from lookups that do not report one.
+	NEGATIVE_TTL               = 60  // TTL in seconds of NXDOMAIN and NODATA answers that do not come with an SOA record.
+	DEFAULT_RPC_TIMEOUT        = 3 * time.Second
+	RPC_IDLE_TIMEOUT           = time.Minute     // Connections to other nodes unused for longer are closed.
+	SKIPPED_RETRY_PERIOD       = 5 * time.Minute // How long successors that stopped answering are tried again, see retrySkipped.
+	FINGER_LOOKUPS_PER_TICK    = 8               // Fingers looked up by each run of fixFingersOnce at most.
 )
 
-// Message types.
+// Message types, see the message package.
 const (
-	PING                   = "ping"                   // Used to check predecessor.
-	ACK                    = "ack"                    // Used for general acknowledgements.
-	GET_SUCCESSOR          = "get_successor"          // Used in RPC call to get node.Successor
-	FIND_SUCCESSOR         = "find_successor"         // Used to find successor.
-	CLOSEST_PRECEDING_NODE = "closest_preceding_node" // Used to find the closest preceding node, given a successor id.
-	GET_PREDECESSOR        = "get_predecessor"        // Used to get the predecessor of some node.
-	NOTIFY                 = "notify"                 // Used to notify a node about a new predecessor.
-	PUT                    = "put"                    // Used to insert a DNS query.
-	GET                    = "get"                    // Used to retrieve a DNS record.
-	SHIFT                  = "shift"               	  // Used to shift entries.
-	EMPTY                  = "empty"                  // Placeholder or undefined message type or errenous communications.
-	REPLICATE              = "replicate"              // Used to replicate data.
+	PING                   = message.PING
+	ACK                    = message.ACK
+	GET_SUCCESSOR          = message.GET_SUCCESSOR
+	FIND_SUCCESSOR         = message.FIND_SUCCESSOR
+	CLOSEST_PRECEDING_NODE = message.CLOSEST_PRECEDING_NODE
+	GET_PREDECESSOR        = message.GET_PREDECESSOR
+	NOTIFY                 = message.NOTIFY
+	PUT                    = message.PUT
+	GET                    = message.GET
+	SHIFT                  = message.SHIFT
+	EMPTY                  = message.EMPTY
+	REPLICATE              = message.REPLICATE
+	LEAVE                  = message.LEAVE
+	SET_SUCCESSOR          = message.SET_SUCCESSOR
+	FLUSH                  = message.FLUSH
+	SYNC                   = message.SYNC
 )
 
+var (
+	ErrNotJoined       = errors.New("node is not part of a network")
+	ErrAlreadyLeft     = errors.New("node already left the network")
+	ErrIDCollision     = errors.New("node ID already in use") // Another node of the network has the same ID at a different address.
+	ErrPeerUnreachable = transport.ErrPeerUnreachable         // Returned by CallRPC when the peer could not be reached or timed out.
+	ErrNoQuorum        = errors.New("quorum not reached")     // Too few replicas acknowledged a write.
+)
+
+// Returned by CallRPC when the peer handled the message but returned an error.
+type RemoteError = transport.RemoteError
+
 /*
 The default method called by all RPCs. This method receives different
 types of requests, and calls the appropriate functions.
 */
 func (node *Node) HandleIncomingMessage(msg *message.RequestMessage, reply *message.ResponseMessage) error {
 	log.Debug().Msgf("Message of type %s received.", msg.Type)
+	if node.hasLeft() {
+		// Fail every message, so that others route around us.
+		return ErrAlreadyLeft
+	}
 	switch msg.Type {
 	case PING:
 		log.Debug().Msg("Received PING message")
 		reply.Type = ACK
 	case GET_SUCCESSOR:
-		log.Debug().Msgf("Received a message to GET SUCCESSOR of %d", node.Nodeid)
-		reply.Nodeid = node.Successor.Nodeid
-		reply.IP = node.Successor.IP
+		log.Debug().Msgf("Received a message to GET SUCCESSOR of %s", node.Nodeid)
+		successor := node.successor()
+		reply.Nodeid = successor.Nodeid
+		reply.IP = successor.IP
+		reply.Successors = node.succList()
 	case FIND_SUCCESSOR:
-		log.Debug().Msgf("Received a message to FIND SUCCESSOR of %d", msg.TargetId)
+		log.Debug().Msgf("Received a message to FIND SUCCESSOR of %s", msg.TargetId)
 		pointer, _ := node.FindSuccessor(msg.TargetId, msg.HopCount)
 		reply.Type = ACK
 		reply.Nodeid = pointer.Nodeid
 		reply.IP = pointer.IP
 	case NOTIFY:
-		log.Debug().Msgf("Received a message to NOTIFY me about a new predecessor %d", msg.TargetId)
-		status := node.Notify(Pointer{Nodeid: msg.TargetId, IP: msg.IP})
-		if status {
+		log.Debug().Msgf("Received a message to NOTIFY me about a new predecessor %s", msg.TargetId)
+		candidate := Pointer{Nodeid: msg.TargetId, IP: msg.IP}
+		if err := node.checkCollision(candidate); err != nil {
+			log.Error().Err(err).Msgf("Refused %s as predecessor", candidate.IP)
+			return err
+		}
+		accepted, previous := node.notify(candidate)
+		if accepted {
 			reply.Type = ACK
+			node.handOff(previous, candidate)
 		}
 	case GET_PREDECESSOR:
 		log.Debug().Msg("Received a message to GET PREDECESSOR")
-		reply.Nodeid = node.Predecessor.Nodeid
-		reply.IP = node.Predecessor.IP
+		predecessor := node.predecessor()
+		reply.Nodeid = predecessor.Nodeid
+		reply.IP = predecessor.IP
 	case GET:
 		log.Debug().Msg("Received a message to GET DNS record")
-		reply.QueryResponse = node.GetQuery(msg.TargetId)
+		reply.QueryResponse = node.getCopy(msg.Owner, msg.TargetId)
 	case SHIFT:
 		log.Debug().Msg("Received a message to GET SOME DNS records")
-		reply.Payload = node.GetShiftRecords(msg.TargetId)
+		if err := node.checkCollision(Pointer{Nodeid: msg.TargetId, IP: msg.IP}); err != nil {
+			log.Error().Err(err).Msgf("Refused to hand off keys to %s", msg.IP)
+			return err
+		}
+		reply.Payload, reply.Replicas = node.GetShiftRecords(msg.TargetId)
+		reply.Type = ACK
 	case PUT:
 		log.Debug().Msg("Received a message to INSERT a query")
 		status := node.PutQuery(msg.TargetId, msg.Payload)
@@ -114,66 +201,242 @@ func (node *Node) HandleIncomingMessage(msg *message.RequestMessage, reply *mess
 		}
 	case REPLICATE:
 		log.Debug().Msg("Received a message to REPLICATE data")
-		node.processReplicate(msg.TargetId, msg.Payload)
+		node.processReplicate(msg.TargetId, msg.Payload, msg.Drop)
+		reply.Type = ACK
+	case SYNC:
+		log.Debug().Msgf("Received a message to SYNC the replicas of %s", msg.TargetId)
+		subtrees, hashes, payload, err := node.processSync(msg.TargetId, msg.Level, msg.Subtrees, msg.Hashes)
+		if err != nil {
+			return fmt.Errorf("invalid SYNC from %s: %w", msg.TargetId, err)
+		}
+		reply.Subtrees, reply.Hashes, reply.Payload = subtrees, hashes, payload
+		reply.Type = ACK
+	case LEAVE:
+		log.Debug().Msgf("Received a message that my predecessor %s is LEAVING", msg.Sender)
+		node.processLeave(msg.Sender, Pointer{Nodeid: msg.TargetId, IP: msg.IP}, msg.Payload)
+		reply.Type = ACK
+	case SET_SUCCESSOR:
+		log.Debug().Msgf("Received a message that my successor %s is LEAVING", msg.Sender)
+		if node.processSetSuccessor(msg.Sender, Pointer{Nodeid: msg.TargetId, IP: msg.IP}) {
+			reply.Type = ACK
+		}
+	case FLUSH:
+		log.Debug().Msgf("Received a message to FLUSH the replicas of %s", msg.Sender)
+		node.dropReplicas(msg.Sender)
 		reply.Type = ACK
 	default:
-		time.Sleep(100 * time.Millisecond)
+		return fmt.Errorf("unknown message type %q", msg.Type)
 	}
 	return nil
 }
 
+/*
+Serves the messages sent by other nodes at addr, in the background.
+*/
+func (node *Node) Listen(addr string) error {
+	return node.transportLayer().Listen(addr, node)
+}
+
 // Create new network (genesis node)
 func (node *Node) CreateNetwork() {
 	log.Info().Msg("> Creating a new network...")
-	node.Successor = Pointer{Nodeid: node.Nodeid, IP: node.IP}
+	myPointer := Pointer{Nodeid: node.Nodeid, IP: node.IP}
+	node.ringMu.Lock()
+	node.Successor = myPointer
 	node.Predecessor = Pointer{}
-	node.FingerTable = make([]Pointer, M)
-	go node.FixFingers()
-	log.Info().Msg("> Finger table has been updated...")
-	for i := 0; i < len(node.FingerTable); i++ {
-		log.Info().Msgf("> Finger[%d]: Nodeid: %d IP: %s", i+1, node.FingerTable[i].Nodeid, node.FingerTable[i].IP)
-	}
-
-	// Initialize SuccList with self.
-	myPointer := Pointer{node.Nodeid, node.IP}
+	node.FingerTable = make([]Pointer, node.idBits())
 	node.SuccList = []Pointer{myPointer}
+	node.left = make(chan struct{})
+	node.ringMu.Unlock()
 
-	go node.stabilize()
-	go node.CheckPredecessor()
-	go node.replicate()
+	node.start()
 }
 
 // Join existing chord network
-func (node *Node) JoinNetwork(helper string) {
+func (node *Node) JoinNetwork(helper string) error {
 	log.Info().Msgf("Contacting node in existing network at address: %s", helper)
-	reply := node.CallRPC(message.RequestMessage{Type: FIND_SUCCESSOR, TargetId: node.Nodeid}, helper)
-	node.Successor = Pointer{Nodeid: reply.Nodeid, IP: reply.IP}
-	log.Info().Msgf("My successor is: Nodeid: %d IP: %s", node.Successor.Nodeid, node.Successor.IP)
-	node.Predecessor = Pointer{}
-	node.FingerTable = make([]Pointer, M)
-	go node.FixFingers()
-	log.Info().Msg("> Finger table has been updated...")
-	for i := 0; i < len(node.FingerTable); i++ {
-		log.Info().Msgf("> Finger[%d]: Nodeid: %d IP: %s", i+1, node.FingerTable[i].Nodeid, node.FingerTable[i].IP)
+	reply, err := node.CallRPC(message.RequestMessage{Type: FIND_SUCCESSOR, TargetId: node.Nodeid}, helper)
+	if err != nil {
+		return fmt.Errorf("could not find my successor through %s: %w", helper, err)
+	}
+	successor := Pointer{Nodeid: reply.Nodeid, IP: reply.IP}
+	log.Info().Msgf("My successor is: Nodeid: %s IP: %s", successor.Nodeid, successor.IP)
+	// The successor of our ID is the node that already has it, if any
+	if successor.Nodeid == node.Nodeid && successor.IP != node.IP {
+		return fmt.Errorf("%w: %s already has ID %s, advertise another address or use more ID bits", ErrIDCollision, successor.IP, node.Nodeid)
 	}
 
+	// Take over the keys in (predecessor, me] from the successor, along with the replicas we should now
+	// hold. The successor keeps a copy as our replica, so retrying a failed join loses nothing.
 	log.Info().Msg("Performing key re-distribution")
-	reply = node.CallRPC(message.RequestMessage{Type: SHIFT, TargetId: node.Successor.Nodeid}, node.Successor.IP)
-	_, ok := node.HashIPStorage[node.Nodeid]
-	if !ok {
-		node.HashIPStorage[node.Nodeid] = map[uint64][]string{}
+	reply, err = node.CallRPC(message.RequestMessage{Type: SHIFT, TargetId: node.Nodeid, IP: node.IP}, successor.IP)
+	if err != nil {
+		return fmt.Errorf("could not take over keys from %s: %w", successor.IP, err)
 	}
-	for hashedWebsite := range reply.Payload {
-		node.HashIPStorage[node.Nodeid][hashedWebsite] = reply.Payload[hashedWebsite]
+	node.PutQuery(node.Nodeid, reply.Payload)
+	for id, replica := range reply.Replicas {
+		node.processReplicate(id, replica, nil)
 	}
+	log.Info().Msgf("Took over %d keys and the replicas of %d nodes", len(reply.Payload), len(reply.Replicas))
 
-	// Initialize SuccList with self.
-	myPointer := Pointer{node.Nodeid, node.IP}
-	node.SuccList = []Pointer{myPointer}
+	node.ringMu.Lock()
+	node.Successor = successor
+	node.Predecessor = Pointer{}
+	node.FingerTable = make([]Pointer, node.idBits())
+	// Filled from the successor's list once we stabilize
+	node.SuccList = []Pointer{successor}
+	node.left = make(chan struct{})
+	node.ringMu.Unlock()
 
+	node.start()
+	return nil
+}
+
+/*
+Starts the periodic tasks of a node that created or joined a network. They stop when it leaves.
+*/
+func (node *Node) start() {
+	if node.Manual {
+		return
+	}
+	go node.FixFingers()
 	go node.stabilize()
 	go node.CheckPredecessor()
 	go node.replicate()
+	go node.deliverHints()
+	go node.sweepExpired()
+}
+
+/*
+Leave the network gracefully, instead of waiting for the neighbours to notice the failure:
+ 1. Stop the periodic tasks
+ 2. Hand off the keys we own to our successor, which also takes over our predecessor (LEAVE). If the
+    successor does not answer, the next one in SuccList is tried.
+ 3. Tell our predecessor that its new successor is the node that took over the keys (SET_SUCCESSOR)
+ 4. Tell the other successors holding our replicas to drop them (FLUSH)
+ 5. Drop our own storage, so that it is not served again after a restart
+
+The last node of a network keeps its storage, as there is nobody to hand it off to.
+*/
+func (node *Node) Leave() error {
+	node.ringMu.Lock()
+	if node.left == nil {
+		node.ringMu.Unlock()
+		return ErrNotJoined
+	}
+	select {
+	case <-node.left:
+		node.ringMu.Unlock()
+		return ErrAlreadyLeft
+	default:
+	}
+	close(node.left)
+	candidates := append([]Pointer{node.Successor}, node.SuccList...)
+	predecessor := node.Predecessor
+	node.ringMu.Unlock()
+	log.Info().Msg("> Leaving the network...")
+
+	myPointer := Pointer{Nodeid: node.Nodeid, IP: node.IP}
+	owned := node.storageCopy(node.Nodeid)
+	heir := Pointer{}
+	for _, pointer := range candidates {
+		if (pointer == myPointer || pointer == Pointer{} || node.departed(pointer)) {
+			continue
+		}
+		reply, err := node.CallRPC(
+			message.RequestMessage{Type: LEAVE, Sender: node.Nodeid, TargetId: predecessor.Nodeid, IP: predecessor.IP, Payload: owned},
+			pointer.IP,
+		)
+		if err == nil && reply.Type == ACK {
+			heir = pointer
+			break
+		}
+	}
+	if (heir == Pointer{}) {
+		if candidates[0] == myPointer {
+			log.Info().Msg("> Last node in the network, keeping the storage")
+			return nil
+		}
+		return fmt.Errorf("could not hand off %d keys: no successor answered", len(owned))
+	}
+	log.Info().Msgf("> Handed off %d keys to Nodeid: %s IP: %s", len(owned), heir.Nodeid, heir.IP)
+
+	if (predecessor != Pointer{} && predecessor != myPointer && !node.departed(predecessor)) {
+		reply, err := node.CallRPC(
+			message.RequestMessage{Type: SET_SUCCESSOR, Sender: node.Nodeid, TargetId: heir.Nodeid, IP: heir.IP},
+			predecessor.IP,
+		)
+		if err != nil || reply.Type != ACK {
+			log.Warn().Msgf("Predecessor Nodeid: %s IP: %s was not updated, it will stabilize on its own", predecessor.Nodeid, predecessor.IP)
+		}
+	}
+
+	// The heir drops our replicas when it takes over the keys, the others are told to.
+	flushed := map[Pointer]bool{myPointer: true, heir: true, {}: true}
+	for _, pointer := range candidates {
+		if flushed[pointer] || node.departed(pointer) {
+			continue
+		}
+		flushed[pointer] = true
+		node.CallRPC(message.RequestMessage{Type: FLUSH, Sender: node.Nodeid}, pointer.IP)
+	}
+
+	// The storage and transport of a virtual node are the host's, which drops and closes them once every
+	// virtual node has left
+	node.storageLock().Lock()
+	if node.host == nil {
+		node.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet)
+		node.trees = nil
+	} else {
+		node.unstoreAll(node.Nodeid)
+	}
+	node.storageLock().Unlock()
+	if node.host == nil {
+		node.transportLayer().Close()
+	}
+	node.queryCache().DeleteFunc(func(ring.ID, CacheEntry) bool { return true })
+	node.writeToStorage()
+	log.Info().Msg("> Left the network")
+	return nil
+}
+
+/*
+Called on the successor of a leaving node, when a LEAVE message is received. It takes over the keys of the
+leaving node, which it only held as replicas so far, and its predecessor.
+*/
+func (node *Node) processLeave(leavingId ring.ID, predecessor Pointer, payload map[ring.ID]message.RRSet) {
+	node.PutQuery(node.Nodeid, payload)
+	node.dropReplicas(leavingId)
+	node.ringMu.Lock()
+	defer node.ringMu.Unlock()
+	if node.Predecessor.Nodeid != leavingId {
+		return
+	}
+	if predecessor.Nodeid == node.Nodeid {
+		// We are the only node left
+		node.Predecessor = Pointer{}
+	} else {
+		node.Predecessor = predecessor
+	}
+}
+
+/*
+Called on the predecessor of a leaving node, when a SET_SUCCESSOR message is received. The fingers that
+pointed to the leaving node now point to its successor, which took over its keys.
+*/
+func (node *Node) processSetSuccessor(leavingId ring.ID, successor Pointer) bool {
+	node.ringMu.Lock()
+	defer node.ringMu.Unlock()
+	if node.Successor.Nodeid != leavingId || (successor == Pointer{}) {
+		return false
+	}
+	node.Successor = successor
+	for i := range node.FingerTable {
+		if node.FingerTable[i].Nodeid == leavingId {
+			node.FingerTable[i] = successor
+		}
+	}
+	return true
 }
 
 /*
@@ -181,19 +444,38 @@ If id falls between its successor, find successor is finished and node
 n returns its successor. Otherwise, n searches its finger table for the
 node whose ID most immediately precedes id, and then invokes find successor
 at that ID
+
+The successor list is checked before the fingers, so that ids just past a failed successor are found
+without going through it, and fingers that do not answer are passed over for the next closest one.
 */
-func (node *Node) FindSuccessor(id uint64, hopCount int) (Pointer, int) {
+func (node *Node) FindSuccessor(id ring.ID, hopCount int) (Pointer, int) {
 	hopCount++
-	if belongsTo(id, node.Nodeid, node.Successor.Nodeid) {
-		return Pointer{Nodeid: node.Successor.Nodeid, IP: node.Successor.IP}, hopCount // Case when this is the first node.
+	successor := node.successor()
+	if belongsTo(id, node.Nodeid, successor.Nodeid) {
+		return successor, hopCount // Case when this is the first node.
 	}
-	p := node.ClosestPrecedingNode(id)
-	if (p != Pointer{} && p.Nodeid != node.Nodeid) {
-
-		reply := node.CallRPC(message.RequestMessage{Type: FIND_SUCCESSOR, TargetId: id, HopCount: hopCount}, p.IP)
-		return Pointer{Nodeid: reply.Nodeid, IP: reply.IP}, hopCount
-	} else {
-		return node.Successor, hopCount
+	// Unless the successor changed since the list was copied from it
+	succList := node.succList()
+	if i := slices.Index(succList, successor); i >= 0 {
+		previous := successor
+		for _, pointer := range succList[i+1:] {
+			if belongsTo(id, previous.Nodeid, pointer.Nodeid) {
+				return pointer, hopCount
+			}
+			previous = pointer
+		}
+	}
+	failed := map[Pointer]bool{}
+	for {
+		p := node.closestPrecedingNode(id, failed)
+		if p == (Pointer{}) || p.Nodeid == node.Nodeid {
+			return successor, hopCount
+		}
+		reply, err := node.CallRPC(message.RequestMessage{Type: FIND_SUCCESSOR, TargetId: id, HopCount: hopCount}, p.IP)
+		if found := (Pointer{Nodeid: reply.Nodeid, IP: reply.IP}); err == nil && found != (Pointer{}) {
+			return found, hopCount
+		}
+		failed[p] = true
 	}
 }
 
@@ -202,13 +484,20 @@ Works jointly with FindSuccessor(id). If id doesn't fall between
 my id, and my immediate successors id, then we find the closest
 preceding node, so we can call find successor on that node.
 */
-func (node *Node) ClosestPrecedingNode(id uint64) Pointer {
-	for i := M - 1; i >= 0; i-- {
-		if belongsTo(node.FingerTable[i].Nodeid, node.Nodeid, id) {
+func (node *Node) ClosestPrecedingNode(id ring.ID) Pointer {
+	return node.closestPrecedingNode(id, nil)
+}
+
+// Same as ClosestPrecedingNode, passing over the fingers in skip.
+func (node *Node) closestPrecedingNode(id ring.ID, skip map[Pointer]bool) Pointer {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
+	for i := len(node.FingerTable) - 1; i >= 0; i-- {
+		if between(node.FingerTable[i].Nodeid, node.Nodeid, id) && !skip[node.FingerTable[i]] {
 			return node.FingerTable[i]
 		}
 	}
-	log.Info().Msgf("Closest Preceding node outside fingertable: Nodeid: %d IP: %s", node.Nodeid, node.IP)
+	log.Info().Msgf("Closest Preceding node outside fingertable: Nodeid: %s IP: %s", node.Nodeid, node.IP)
 	return Pointer{Nodeid: node.Nodeid, IP: node.IP}
 }
 
@@ -219,26 +508,60 @@ their finger tables, and it is how existing nodes incorporate
 new nodes into their finger tables.
 */
 func (node *Node) FixFingers() {
+	for node.wait(1 * time.Second) {
+		node.fixFingersOnce()
+		node.syncStorage()
+	}
+}
 
-	for {
-		time.Sleep(1 * time.Second)
-		log.Debug().Msg("Fixing fingers...")
-		for id := range node.FingerTable {
-			nodePlusTwoI := (node.Nodeid + uint64(math.Pow(2, float64(id))))
-			power := uint64(math.Pow(2, float64(M)))
-			if nodePlusTwoI > power {
-				nodePlusTwoI -= power
-			}
-			node.FingerTable[id], _ = node.FindSuccessor(uint64(nodePlusTwoI), 0)
+/*
+Refreshes the fingers round-robin, starting after the one the previous call stopped at, until it has looked
+up FINGER_LOOKUPS_PER_TICK of them or gone around the table. Starts grow with the finger index, so a finger
+whose start is not after the last node found, the successor at first, is that node and needs no lookup: most
+fingers of a ring much smaller than the ID space are one of a few nodes, and a round takes a handful of lookups.
+*/
+func (node *Node) fixFingersOnce() {
+	log.Debug().Msg("Fixing fingers...")
+	bits := node.idBits()
+	node.ringMu.RLock()
+	i := node.nextFinger
+	node.ringMu.RUnlock()
+	known := node.successor()
+	for lookups, fixed := 0, 0; lookups < FINGER_LOOKUPS_PER_TICK && fixed < bits; fixed++ {
+		i = (i + 1) % bits
+		if i == 0 {
+			// Back to the smallest start, which the last node found may be far after
+			known = node.successor()
 		}
-		// it has just restarted, so it needs to read from storage
-		if len(node.HashIPStorage) == 0 {
-			go node.readFromStorage()
+		start := node.Nodeid.AddPow2(i, bits)
+		if !between(start, node.Nodeid, known.Nodeid) && start != known.Nodeid {
+			known, _ = node.FindSuccessor(start, 0)
+			lookups++
 		}
+		node.ringMu.Lock()
+		node.FingerTable[i] = known
+		node.nextFinger = i
+		node.ringMu.Unlock()
+	}
+}
 
-		go node.writeToStorage()
-
+/*
+Persists the storage in the background, after reading it back if the node has just restarted.
+*/
+func (node *Node) syncStorage() {
+	// The storage of a host is shared, and persisted by its first virtual node
+	if node.host != nil && node.host.Nodes[0] != node {
+		return
 	}
+	// it has just restarted, so it needs to read from storage
+	node.storageLock().RLock()
+	restarted := len(node.HashIPStorage) == 0
+	node.storageLock().RUnlock()
+	if restarted {
+		go node.readFromStorage()
+	}
+
+	go node.writeToStorage()
 }
 
 /*
@@ -252,59 +575,143 @@ to change its predecessor to n. The successor does this only if it
 knows of no closer predecessor than n.
 */
 func (node *Node) stabilize() {
-	for {
-		time.Sleep(1 * time.Second)
-		reply := node.CallRPC(
-			message.RequestMessage{Type: GET_PREDECESSOR, TargetId: node.Successor.Nodeid, IP: node.Successor.IP},
-			node.Successor.IP,
-		)
-
-		// [3000, 3001, 3000]
+	for node.wait(1 * time.Second) {
+		node.stabilizeOnce()
+	}
+}
 
-		// Current successor is dead. Look at successor list for next successor.
-		if reply.Type == EMPTY {
-			// get next successor from SuccList and make it your successor
-			for _, pointer := range node.SuccList[1:] {
-				if node.checkSuccessorAlive(pointer) {
-					node.Successor = pointer
-				}
+func (node *Node) stabilizeOnce() {
+	successor := node.retrySkipped(node.successor())
+	reply, err := node.CallRPC(
+		message.RequestMessage{Type: GET_PREDECESSOR, TargetId: successor.Nodeid, IP: successor.IP},
+		successor.IP,
+	)
+
+	// [3000, 3001, 3000]
+
+	// Current successor is dead. Look at successor list for next successor.
+	if err != nil {
+		// The first live node after it in SuccList becomes our successor
+		skipped := []Pointer{successor}
+		for _, pointer := range node.succList() {
+			if pointer == successor {
+				continue
 			}
-
-			// Current successor is alive. Check if it's predecessor lies between you and your current successor. If yes, node.Successor = the middle fella
-		} else {
-			sucessorsPredecessor := Pointer{Nodeid: reply.Nodeid, IP: reply.IP}
-			if (sucessorsPredecessor != Pointer{}) {
-				// The new dude in between you and your successor is not dead, then my true successor is the new dude. Or you're the only dude.
-				if between(sucessorsPredecessor.Nodeid, node.Nodeid, node.Successor.Nodeid) {
-					node.Successor = Pointer{Nodeid: sucessorsPredecessor.Nodeid, IP: sucessorsPredecessor.IP}
-				}
+			if node.checkSuccessorAlive(pointer) {
+				node.ringMu.Lock()
+				// Those skipped before lie before the successor that just failed
+				node.skipped = append(node.skipped, skipped...)
+				node.skippedAt = node.now()
+				node.ringMu.Unlock()
+				successor = pointer
+				break
 			}
+			skipped = append(skipped, pointer)
 		}
 
-		// Notify your new successor (whoever it is) that you are it's predecessor
-		reply = node.CallRPC(
-			message.RequestMessage{Type: NOTIFY, TargetId: node.Nodeid, IP: node.IP},
-			node.Successor.IP,
-		)
-		if reply.Type == ACK {
-			log.Debug().Msgf("Successfully notified successor of it's new predecessor Nodeid: %d IP: %s\n", node.Nodeid, node.IP)
+		// Current successor is alive. Check if it's predecessor lies between you and your current successor. If yes, node.Successor = the middle fella
+	} else {
+		sucessorsPredecessor := Pointer{Nodeid: reply.Nodeid, IP: reply.IP}
+		if (sucessorsPredecessor != Pointer{}) {
+			// The new dude in between you and your successor is not dead, then my true successor is the new dude. Or you're the only dude.
+			if between(sucessorsPredecessor.Nodeid, node.Nodeid, successor.Nodeid) {
+				successor = Pointer{Nodeid: sucessorsPredecessor.Nodeid, IP: sucessorsPredecessor.IP}
+			}
 		}
+	}
+	node.ringMu.Lock()
+	node.Successor = successor
+	node.ringMu.Unlock()
+
+	// Notify your new successor (whoever it is) that you are it's predecessor
+	reply, err = node.CallRPC(
+		message.RequestMessage{Type: NOTIFY, TargetId: node.Nodeid, IP: node.IP},
+		successor.IP,
+	)
+	// E.g. because it already has a predecessor with our ID
+	var remote *RemoteError
+	if errors.As(err, &remote) {
+		log.Warn().Err(err).Msg("Successor refused to be notified")
+	}
+	if reply.Type == ACK {
+		log.Debug().Msgf("Successfully notified successor of it's new predecessor Nodeid: %s IP: %s\n", node.Nodeid, node.IP)
+	}
 
-		// Recompute SuccList
-		node.maintainSuccList()
-		// replicate
+	// Recompute SuccList
+	node.maintainSuccList(successor)
+}
+
+/*
+Returns an error wrapping ErrIDCollision if x has the ID of this node or of its predecessor, at another
+address. Nodes at the same address are the same node, e.g. one that restarted before the ring noticed.
+*/
+func (node *Node) checkCollision(x Pointer) error {
+	predecessor := node.predecessor()
+	for _, known := range []Pointer{{Nodeid: node.Nodeid, IP: node.IP}, predecessor} {
+		if (known != Pointer{}) && x.Nodeid == known.Nodeid && x.IP != known.IP {
+			return fmt.Errorf("%w: %s is the ID of %s, not of %s", ErrIDCollision, x.Nodeid, known.IP, x.IP)
+		}
 	}
+	return nil
 }
 
 /*
 x thinks it might be nodes predecessor
 */
 func (node *Node) Notify(x Pointer) bool {
-	if (node.Predecessor == Pointer{} || between(x.Nodeid, node.Predecessor.Nodeid, node.Nodeid)) {
+	accepted, _ := node.notify(x)
+	return accepted
+}
+
+/*
+Same as Notify, but also returns the predecessor x replaced.
+*/
+func (node *Node) notify(x Pointer) (bool, Pointer) {
+	node.ringMu.Lock()
+	defer node.ringMu.Unlock()
+	previous := node.Predecessor
+	if (previous == Pointer{} || between(x.Nodeid, previous.Nodeid, node.Nodeid)) {
 		node.Predecessor = Pointer{Nodeid: x.Nodeid, IP: x.IP}
-		return true
+		return true, previous
 	}
-	return false
+	return false, previous
+}
+
+/*
+Hands off the keys in (previous, x] to x, which replaced previous as our predecessor, and keeps them as its
+replica. Without a previous predecessor, every key outside (x, us] is handed off. A new node normally takes
+them over with SHIFT when it joins, but not if it joined through nodes that did not know the ring around it
+yet, e.g. while other nodes or the other virtual nodes of its process were joining. The keys are taken back
+if x cannot be reached.
+*/
+func (node *Node) handOff(previous, x Pointer) {
+	inRange := func(key ring.ID) bool { return belongsTo(key, previous.Nodeid, x.Nodeid) }
+	if (previous == Pointer{}) {
+		inRange = func(key ring.ID) bool { return !belongsTo(key, x.Nodeid, node.Nodeid) }
+	}
+	node.storageLock().Lock()
+	moved := make(map[ring.ID]message.RRSet)
+	for key, rrset := range node.HashIPStorage[node.Nodeid] {
+		if inRange(key) {
+			moved[key] = rrset
+			node.unstore(node.Nodeid, key)
+		}
+	}
+	for key, rrset := range moved {
+		node.store(x.Nodeid, key, rrset)
+	}
+	node.storageLock().Unlock()
+	if len(moved) == 0 {
+		return
+	}
+
+	reply, err := node.CallRPC(message.RequestMessage{Type: PUT, TargetId: x.Nodeid, Payload: moved}, x.IP)
+	if err != nil || reply.Type != ACK {
+		log.Warn().Err(err).Msgf("Could not hand off %d keys to new predecessor Nodeid: %s IP: %s", len(moved), x.Nodeid, x.IP)
+		node.PutQuery(node.Nodeid, moved)
+		return
+	}
+	log.Info().Msgf("> Handed off %d keys to new predecessor Nodeid: %s IP: %s", len(moved), x.Nodeid, x.IP)
 }
 
 /*
@@ -313,47 +720,223 @@ predecessor pointer if n.predecessor has failed; this allows it to accept
 a new predecessor in notify.
 */
 func (node *Node) CheckPredecessor() {
-	for {
-		time.Sleep(1 * time.Second)
-		if (node.Predecessor == Pointer{}) {
+	for node.wait(1 * time.Second) {
+		node.checkPredecessorOnce()
+	}
+}
+
+func (node *Node) checkPredecessorOnce() {
+	predecessor := node.predecessor()
+	if (predecessor == Pointer{}) {
+		return
+	}
+	if _, err := node.CallRPC(message.RequestMessage{Type: PING}, predecessor.IP); err == nil {
+		log.Debug().Msgf("Predecessor Nodeid: %s IP: %s is alive", predecessor.Nodeid, predecessor.IP)
+		node.ringMu.Lock()
+		adopt := node.adopt
+		node.adopt = false
+		node.ringMu.Unlock()
+		if adopt {
+			node.adoptReplicas(predecessor)
+		}
+		return
+	}
+	// Take over the keys of the failed predecessor, for which we hold a replica
+	node.storageLock().Lock()
+	hashMap, ok := node.HashIPStorage[predecessor.Nodeid]
+	if ok {
+		for id, ip_cache := range hashMap {
+			node.store(node.Nodeid, id, ip_cache)
+		}
+		node.unstoreAll(predecessor.Nodeid)
+	}
+	node.storageLock().Unlock()
+	// Unless a new predecessor notified us in the meantime
+	node.ringMu.Lock()
+	if node.Predecessor == predecessor {
+		node.Predecessor = Pointer{}
+	}
+	node.adopt = true
+	node.ringMu.Unlock()
+}
+
+/*
+Takes over the keys of the nodes between predecessor and us, for which we hold a replica. They have failed
+along with our previous predecessor, e.g. as virtual nodes of the same process, and their keys are now ours.
+*/
+func (node *Node) adoptReplicas(predecessor Pointer) {
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
+	for id, storage := range node.HashIPStorage {
+		if !between(id, predecessor.Nodeid, node.Nodeid) || node.hosted(id) {
 			continue
 		}
-		reply := node.CallRPC(message.RequestMessage{Type: PING}, node.Predecessor.IP)
-		if reply.Type == EMPTY {
-			hashMap, ok := node.HashIPStorage[node.Predecessor.Nodeid]
-			if ok {
-				for id, ip_cache := range hashMap {
-					_, ok := node.HashIPStorage[node.Nodeid]
-					if !ok {
-						node.HashIPStorage[node.Nodeid] = make(map[uint64][]string)
-					}
-					node.HashIPStorage[node.Nodeid][id] = ip_cache
-				}
-				delete(node.HashIPStorage, node.Predecessor.Nodeid)
-				node.Predecessor = Pointer{}
-			}
+		for key, rrset := range storage {
+			node.store(node.Nodeid, key, rrset)
+		}
+		node.unstoreAll(id)
+		log.Info().Msgf("Took over the keys of failed node %s", id)
+	}
+}
 
-		} else {
-			log.Debug().Msgf("Predecessor Nodeid: %d IP: %s is alive", node.Predecessor.Nodeid, node.Predecessor.IP)
+/*
+Rebuilds SuccList from the list of our successor, which is one node ahead of ours: the successor followed by
+its list, truncated to SuccListLength. The list stops before our own pointer, which it reaches in rings of
+fewer nodes; it is only our successor in a ring of one. If the successor does not answer, the list is kept
+as it is until stabilize moves on to the next one.
+*/
+func (node *Node) maintainSuccList(successor Pointer) {
+	myPointer := Pointer{Nodeid: node.Nodeid, IP: node.IP}
+	succList := []Pointer{successor}
+	if successor != myPointer {
+		reply, err := node.CallRPC(message.RequestMessage{Type: GET_SUCCESSOR}, successor.IP)
+		if err != nil {
+			return
+		}
+		for _, pointer := range reply.Successors {
+			if len(succList) == node.succListLength() || pointer == myPointer {
+				break
+			}
+			if (pointer != Pointer{}) {
+				succList = append(succList, pointer)
+			}
 		}
 	}
+	node.ringMu.Lock()
+	node.SuccList = succList
+	node.ringMu.Unlock()
 }
 
-func (node *Node) maintainSuccList() {
+/*
+Returns a successor skipped by stabilize that answers again, if it still lies between us and successor, or
+successor otherwise. One of them is tried per call, in turn, so that failed ones do not hold the others back.
 
-	mu.Lock()
-	myPointer := Pointer{Nodeid: node.Nodeid, IP: node.IP}
-	node.SuccList = []Pointer{myPointer}
-	for i := 0; i < REPLICATION_FACTOR; i++ {
-		lastSucc := node.SuccList[len(node.SuccList)-1]
-		reply := node.CallRPC(message.RequestMessage{Type: GET_SUCCESSOR}, lastSucc.IP)
-		nextSucc := Pointer{Nodeid: reply.Nodeid, IP: reply.IP}
-		node.SuccList = append(node.SuccList, nextSucc)
+A successor that does not answer may be partitioned away rather than failed. The nodes on each side then
+stabilize into a ring of their own, and would not find each other again once the network heals: taking the
+skipped successor back lets stabilize merge them. Skipped successors are forgotten after
+SKIPPED_RETRY_PERIOD, or once one of them is taken back.
+*/
+func (node *Node) retrySkipped(successor Pointer) Pointer {
+	node.ringMu.Lock()
+	if node.now().Sub(node.skippedAt) > SKIPPED_RETRY_PERIOD {
+		node.skipped = nil
+	}
+	node.skipped = slices.DeleteFunc(node.skipped, func(pointer Pointer) bool {
+		return !between(pointer.Nodeid, node.Nodeid, successor.Nodeid)
+	})
+	if len(node.skipped) == 0 {
+		node.ringMu.Unlock()
+		return successor
+	}
+	candidate := node.skipped[0]
+	node.skipped = append(node.skipped[1:], candidate)
+	node.ringMu.Unlock()
+
+	if !node.checkSuccessorAlive(candidate) {
+		return successor
 	}
-	mu.Unlock()
+	log.Info().Msgf("Successor Nodeid: %s IP: %s answers again", candidate.Nodeid, candidate.IP)
+	node.ringMu.Lock()
+	node.skipped = nil
+	node.ringMu.Unlock()
+	return candidate
 }
 
 func (node *Node) checkSuccessorAlive(pointer Pointer) bool {
-	reply := node.CallRPC(message.RequestMessage{Type: PING}, pointer.IP)
-	return reply.Type == ACK
+	reply, err := node.CallRPC(message.RequestMessage{Type: PING}, pointer.IP)
+	return err == nil && reply.Type == ACK
+}
+
+/*
+Runs each periodic task once, except persisting the storage. Nodes started with Manual set rely on the caller
+to call it, which lets a simulation decide when and in which order nodes run.
+*/
+func (node *Node) Tick() {
+	if node.hasLeft() {
+		return
+	}
+	node.fixFingersOnce()
+	node.stabilizeOnce()
+	node.checkPredecessorOnce()
+	node.replicateOnce()
+	node.deliverHintsOnce()
+	node.sweepExpiredOnce(node.now())
+}
+
+/*
+Copy of the ring state of a node, see Node.Ring.
+*/
+type RingState struct {
+	Successor   Pointer
+	Predecessor Pointer
+	SuccList    []Pointer
+	FingerTable []Pointer
+}
+
+/*
+Returns a copy of the ring state of the node, which can be inspected while the node keeps running.
+*/
+func (node *Node) Ring() RingState {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
+	return RingState{
+		Successor:   node.Successor,
+		Predecessor: node.Predecessor,
+		SuccList:    append([]Pointer(nil), node.SuccList...),
+		FingerTable: append([]Pointer(nil), node.FingerTable...),
+	}
+}
+
+/*
+Used by the periodic tasks to wait for their next run. Returns false once the node has left the network,
+in which case the task must stop.
+*/
+func (node *Node) wait(d time.Duration) bool {
+	node.ringMu.RLock()
+	left := node.left
+	node.ringMu.RUnlock()
+	select {
+	case <-left:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (node *Node) joined() bool {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
+	return node.left != nil
+}
+
+func (node *Node) hasLeft() bool {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
+	if node.left == nil {
+		return false
+	}
+	select {
+	case <-node.left:
+		return true
+	default:
+		return false
+	}
+}
+
+func (node *Node) successor() Pointer {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
+	return node.Successor
+}
+
+func (node *Node) predecessor() Pointer {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
+	return node.Predecessor
+}
+
+func (node *Node) succList() []Pointer {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
+	return append([]Pointer(nil), node.SuccList...)
 }
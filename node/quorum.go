@@ -0,0 +1,255 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+Keys are stored on N = ReplicationFactor + 1 nodes: their owner, and the replica holders it picks from its
+successor list. Writes go to all of them and succeed once WriteQuorum acknowledge; reads ask ReadQuorum of them
+and keep the newest copy, so that a read sees the last successful write as long as ReadQuorum + WriteQuorum > N.
+
+The node serving the query coordinates, rather than the owner, so that keys can still be read and written
+through the replica holders while the owner is down. Quorums are capped at the number of nodes holding a
+copy, which is less than N in rings of fewer processes.
+
+Reads repair the replicas they find stale: the newest copy is pushed in the background to the nodes that
+returned an older one or none. With ReadRepair, reads consult every node of the replica set rather than
+ReadQuorum of them, so that every stale replica of a key is repaired when it is read.
+*/
+
+/*
+Counters of the read repairs done by a node as the coordinator of reads.
+*/
+type RepairStats struct {
+	Reads     uint64 // Reads that compared the copies of more than one node.
+	Divergent uint64 // Reads whose copies differed.
+	Repairs   uint64 // Stale copies the newest one was pushed to.
+	Failed    uint64 // Pushes that were not acknowledged.
+}
+
+/*
+Returns a copy of the read repair counters of the node.
+*/
+func (node *Node) RepairStats() RepairStats {
+	node.syncMu.Lock()
+	defer node.syncMu.Unlock()
+	return node.repairStats
+}
+
+func (node *Node) countRepair(count func(stats *RepairStats)) {
+	node.syncMu.Lock()
+	count(&node.repairStats)
+	node.syncMu.Unlock()
+}
+
+/*
+Reply of a node of the replica set, see gather.
+*/
+type answer struct {
+	from  Pointer
+	reply message.ResponseMessage
+}
+
+/*
+Looks up key, owned by owner, on ReadQuorum nodes of its replica set, or all of them with ReadRepair, and
+returns the newest copy, or nil if none of them has it. The nodes that returned an older copy or none are
+repaired in the background. If fewer nodes answer, the copies they returned are used anyway, since they are
+better than asking legacy DNS again. Returns an error wrapping ErrPeerUnreachable if no node answered.
+*/
+func (node *Node) QuorumGet(owner Pointer, key ring.ID) (*message.RRSet, error) {
+	set := node.replicaSet(owner)
+	need := min(node.readQuorum(), len(set))
+	wait := need
+	if node.ReadRepair {
+		wait = len(set)
+	}
+	answers := node.gather(set, wait, wait, message.RequestMessage{Type: GET, TargetId: key, Owner: owner.Nodeid}, func(message.ResponseMessage) bool {
+		return true
+	})
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("%w: no replica of %s answered", ErrPeerUnreachable, key)
+	}
+	if len(answers) < need {
+		log.Warn().Msgf("Read quorum of %s not reached: %d of %d replicas answered", key, len(answers), need)
+	}
+	var newest *message.RRSet
+	for _, answer := range answers {
+		if rrset := answer.reply.QueryResponse; rrset != nil && (newest == nil || rrset.Newer(*newest)) {
+			newest = rrset
+		}
+	}
+	if newest == nil || len(answers) < 2 {
+		return newest, nil
+	}
+	var stale []Pointer
+	for _, answer := range answers {
+		if rrset := answer.reply.QueryResponse; rrset == nil || newest.Newer(*rrset) {
+			stale = append(stale, answer.from)
+		}
+	}
+	node.countRepair(func(stats *RepairStats) {
+		stats.Reads++
+		if len(stale) > 0 {
+			stats.Divergent++
+		}
+	})
+	if len(stale) > 0 {
+		if node.Manual {
+			node.repair(owner, key, *newest, stale)
+		} else {
+			go node.repair(owner, key, *newest, stale)
+		}
+	}
+	return newest, nil
+}
+
+/*
+Pushes rrset, the newest copy of key read from the replica set of owner, to the nodes of it that returned an
+older copy or none.
+*/
+func (node *Node) repair(owner Pointer, key ring.ID, rrset message.RRSet, stale []Pointer) {
+	msg := message.RequestMessage{Type: PUT, TargetId: owner.Nodeid, Payload: map[ring.ID]message.RRSet{key: rrset}}
+	for _, pointer := range stale {
+		reply, err := node.callOrHint(msg, pointer)
+		ok := err == nil && reply.Type == ACK
+		node.countRepair(func(stats *RepairStats) {
+			if ok {
+				stats.Repairs++
+			} else {
+				stats.Failed++
+			}
+		})
+		if ok {
+			log.Debug().Msgf("Repaired the copy of %s on %s", key, pointer.IP)
+		}
+	}
+}
+
+/*
+Stores rrset under key, owned by owner, on every node of its replica set, as a new version that replaces the
+copies they hold. Returns once WriteQuorum of them acknowledged it, or an error wrapping ErrNoQuorum once
+they all answered without reaching it. The others still get the write, as a hint once they are back if they
+cannot be reached, or else through the owner's next replication.
+*/
+func (node *Node) QuorumPut(owner Pointer, key ring.ID, rrset message.RRSet) error {
+	set := node.replicaSet(owner)
+	need := min(node.writeQuorum(), len(set))
+	rrset.Version = node.clock.Now(node.now(), node.Nodeid)
+	msg := message.RequestMessage{Type: PUT, TargetId: owner.Nodeid, Payload: map[ring.ID]message.RRSet{key: rrset}}
+	answers := node.gather(set, len(set), need, msg, func(reply message.ResponseMessage) bool {
+		return reply.Type == ACK
+	})
+	if len(answers) < need || need == 0 {
+		return fmt.Errorf("%w: %d of %d replicas of %s acknowledged the write", ErrNoQuorum, len(answers), need, key)
+	}
+	return nil
+}
+
+/*
+Returns the nodes holding a copy of the keys of owner: owner, followed by the replica holders it picks from
+its successor list. If owner does not answer, they are found through its successor, without owner. Returns
+nil if owner is not known, e.g. because the lookup of the key failed, or if its successor cannot be told apart
+from it yet, until the ring stabilizes around it.
+*/
+func (node *Node) replicaSet(owner Pointer) []Pointer {
+	if owner == (Pointer{}) {
+		return nil
+	}
+	if owner == (Pointer{Nodeid: node.Nodeid, IP: node.IP}) {
+		return append([]Pointer{owner}, node.replicaHolders()...)
+	}
+	reply, err := node.CallRPC(message.RequestMessage{Type: GET_SUCCESSOR}, owner.IP)
+	if err == nil {
+		return append([]Pointer{owner}, pickReplicaHolders(owner.IP, reply.Successors, node.replicationFactor())...)
+	}
+	successor, _ := node.FindSuccessor(owner.Nodeid.AddPow2(0, node.idBits()), 0)
+	if successor == owner {
+		return nil
+	}
+	successors := []Pointer{successor}
+	if reply, err := node.CallRPC(message.RequestMessage{Type: GET_SUCCESSOR}, successor.IP); err == nil {
+		successors = append(successors, reply.Successors...)
+	}
+	return pickReplicaHolders(owner.IP, successors, node.replicationFactor())
+}
+
+/*
+Sends msg to the nodes of set, to the first fanout of them at once, and to the next one each time a node
+fails to answer or its reply is not accepted. Returns the accepted replies and the nodes they came from once
+need of them are in, or once every node was tried. Messages still in flight then complete in the background.
+
+Writes (PUT) to nodes that cannot be reached are kept as hints, see callOrHint. Manual nodes send the messages
+one at a time, in the same order, so that a simulation stays on one goroutine.
+*/
+func (node *Node) gather(set []Pointer, fanout, need int, msg message.RequestMessage, accept func(message.ResponseMessage) bool) []answer {
+	call := func(pointer Pointer) (message.ResponseMessage, error) {
+		if msg.Type == PUT {
+			return node.callOrHint(msg, pointer)
+		}
+		return node.CallRPC(msg, pointer.IP)
+	}
+	if node.Manual {
+		var answers []answer
+		for i, pointer := range set {
+			if i >= fanout && len(answers) >= need {
+				break
+			}
+			if reply, err := call(pointer); err == nil && accept(reply) {
+				answers = append(answers, answer{pointer, reply})
+			}
+		}
+		return answers
+	}
+	type result struct {
+		answer
+		ok bool
+	}
+	// Buffered for every node, so that the messages left in flight do not block
+	results := make(chan result, len(set))
+	send := func(pointer Pointer) {
+		go func() {
+			reply, err := call(pointer)
+			results <- result{answer{pointer, reply}, err == nil && accept(reply)}
+		}()
+	}
+	next, pending := 0, 0
+	for ; next < min(fanout, len(set)); next++ {
+		send(set[next])
+		pending++
+	}
+	var answers []answer
+	for len(answers) < need && pending > 0 {
+		r := <-results
+		pending--
+		if r.ok {
+			answers = append(answers, r.answer)
+		} else if next < len(set) {
+			send(set[next])
+			next++
+			pending++
+		}
+	}
+	return answers
+}
+
+/*
+Returns the copy of key held for owner, which is ours if owner is zero or us. A replica held for another node
+may have been taken over since its owner failed, in which case it is found among our own keys.
+*/
+func (node *Node) getCopy(owner, key ring.ID) *message.RRSet {
+	if owner.IsZero() || owner == node.Nodeid {
+		return node.GetQuery(key)
+	}
+	node.storageLock().RLock()
+	rrset, ok := node.HashIPStorage[owner][key]
+	node.storageLock().RUnlock()
+	if ok && !rrset.Expired(node.now()) {
+		return &rrset
+	}
+	return node.GetQuery(key)
+}
@@ -9,23 +9,74 @@ package node
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/fauzxan/dns-chord/v2/cache"
+	"github.com/fauzxan/dns-chord/v2/dns"
 	"github.com/fauzxan/dns-chord/v2/message"
-	"github.com/fauzxan/dns-chord/v2/utility"
+	"github.com/fauzxan/dns-chord/v2/ring"
 	"github.com/rs/zerolog/log"
 )
 
 /*
-Used for in-memory-storage. Used to maintain the list of recent queries, and improve query speed.
+Used for in-memory-storage. Entries of the LRU cache of recent queries, used to improve query speed.
 */
-type LRUCache struct {
-	value     []string // List of values corresponding to websites records.
-	cacheTime uint64   // Counter to indicate the timestamp of the entry. Used for kicking out Least Recently Used.
+type CacheEntry struct {
+	value message.RRSet // Records of the website.
+	owner Pointer       // Node that stores the records in the chord network.
+}
+
+// Where the records of a query were found.
+const (
+	SOURCE_CACHE   = "cache"   // Local LRU cache.
+	SOURCE_STORAGE = "storage" // Local HashIPStorage, because this node owns the website.
+	SOURCE_CHORD   = "chord"   // GET from the replicas of the owner in the chord network.
+	SOURCE_LEGACY  = "legacy"  // Legacy DNS, after which the records were PUT into the chord network.
+)
+
+// Errors returned by QueryDNS, wrapped in a QueryError.
+var (
+	ErrNameNotFound    = dns.ErrNameError                                            // The website does not exist.
+	ErrLegacyLookup    = errors.New("legacy DNS lookup failed")                      // Legacy DNS could not be reached or failed.
+	ErrUnsupportedType = fmt.Errorf("%w: record type not supported", dns.ErrRefused) // The host's resolver cannot look up the record type.
+)
+
+/*
+Outcome of a successful QueryDNS call.
+*/
+type QueryResult struct {
+	Website   string   // Queried website, lower case and without the trailing dot.
+	Type      uint16   // Queried record type.
+	Records   []dns.RR // Answer records, possibly starting with a CNAME chain. Empty if the website has no records of Type.
+	Authority []dns.RR // SOA record explaining a negative answer, if known.
+	Source    string   // One of the SOURCE_* constants.
+	HopCount  int      // Hops taken by FindSuccessor, 0 if the chord network was not consulted.
+	Owner     Pointer  // Node that stores the website in the chord network.
+}
+
+/*
+Returned by QueryDNS when the website could not be resolved. Err is one of the Err* variables above.
+*/
+type QueryError struct {
+	Website string
+	Err     error
+	Cause   error // Underlying error, if any.
+}
+
+func (e *QueryError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v: %v", e.Website, e.Err, e.Cause)
+	}
+	return fmt.Sprintf("%s: %v", e.Website, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
 }
 
 /*
@@ -35,217 +86,436 @@ Mother code for all DNS query logic. It executes one of the following logic path
 
 2. Query node -> check local cache -> query local storage -> put in local cache -> return entry
 
-3. Query node -> check local cache -> query local storage -> find successor, and send get -> put in local cache -> return entry
+3. Query node -> check local cache -> query local storage -> find successor, and get from its replicas -> put in local cache -> return entry
 
-4. Query node -> check local cache -> query local storage -> find successor, and send get -> query legacy DNS -> send to appropriate node, or self -> put in local cache -> return entry
+4. Query node -> check local cache -> query local storage -> find successor, and get from its replicas -> query legacy DNS -> put to its replicas -> put in local cache -> return entry
+
+Local storage is only used when reads do not need other replicas, see QuorumGet.
+
+On failure, the returned error is a *QueryError.
 */
-func (node *Node) QueryDNS(website string) {
-	if node.CachedQuery == nil {
-		node.CachedQuery = make(map[uint64]LRUCache)
+func (node *Node) QueryDNS(website string, rrtype uint16) (QueryResult, error) {
+	website = strings.ToLower(strings.TrimSuffix(website, "."))
+	result := QueryResult{Website: website, Type: rrtype}
+	hashedWebsite := node.hash(message.RRSetKey(website, rrtype))
+	now := node.now()
+	ip_addr, ok := node.queryCache().Get(hashedWebsite)
+	if ok && ip_addr.value.Expired(now) {
+		node.queryCache().Delete(hashedWebsite)
+	} else if ok {
+		log.Debug().Msg("Retrieving from LRU cache")
+		result.Source, result.Owner = SOURCE_CACHE, ip_addr.owner
+		return answerFrom(result, ip_addr.value, now)
+	}
+	node.storageLock().RLock()
+	rrset, ok := node.HashIPStorage[node.Nodeid][hashedWebsite]
+	node.storageLock().RUnlock()
+	log.Debug().Msgf("> The Website %s %s has been hashed to %s", website, dns.TypeString(rrtype), hashedWebsite)
+	// Our own copy is enough unless reads must consult our replicas as well
+	if ok && !rrset.Expired(now) && min(node.readQuorum(), 1+len(node.replicaHolders())) == 1 {
+		log.Debug().Msg("Retrieving from Local Storage")
+		result.Source, result.Owner = SOURCE_STORAGE, Pointer{Nodeid: node.Nodeid, IP: node.IP}
+		return answerFrom(result, rrset, now)
+	}
+	succPointer, hopCount := node.FindSuccessor(hashedWebsite, 0)
+	result.HopCount, result.Owner = hopCount, succPointer
+	log.Debug().Msgf("> The Website would be stored at it's succesor Nodeid: %s IP: %s", succPointer.Nodeid, succPointer.IP)
+	// Replicas do not serve expired records, so a miss here also covers refreshing them from legacy DNS.
+	if found, _ := node.QuorumGet(succPointer, hashedWebsite); found != nil {
+		log.Debug().Msg("Retrieving from Chord Network")
+		result.Source = SOURCE_CHORD
+		return answerFrom(result, *found, now)
 	}
-	node.CacheTime += 1
 
-	if strings.HasPrefix(website, "www.") {
-		log.Info().Msg("Removing Prefix")
-		website = website[4:]
+	// Negative answers are stored in the network as well, so that repeated lookups of names that do not
+	// exist are answered by the owner instead of legacy DNS (RFC 2308).
+	records, authority, err := node.lookupLegacy(website, rrtype)
+	switch {
+	case errors.Is(err, ErrNameNotFound) && node.Upstream == nil:
+		// The host's resolver cannot tell NXDOMAIN from NODATA for sure, see lookupHost, so its answer is
+		// not stored where it would hide the name from every node.
+		result.Source = SOURCE_LEGACY
+		return answerFrom(result, message.NewNegativeRRSet(website, rrtype, dns.RcodeNameError, nil, NEGATIVE_TTL, now), now)
+	case errors.Is(err, ErrNameNotFound):
+		rrset = message.NewNegativeRRSet(website, rrtype, dns.RcodeNameError, authority, NEGATIVE_TTL, now)
+	case errors.Is(err, ErrUnsupportedType):
+		return result, &QueryError{Website: website, Err: ErrUnsupportedType, Cause: err}
+	case err != nil:
+		return result, &QueryError{Website: website, Err: ErrLegacyLookup, Cause: err}
+	case len(records) == 0:
+		rrset = message.NewNegativeRRSet(website, rrtype, dns.RcodeSuccess, authority, NEGATIVE_TTL, now)
+	default:
+		rrset = message.NewRRSet(website, rrtype, records, DEFAULT_TTL, now)
 	}
-	hashedWebsite := utility.GenerateHash(website)
-	ip_addr, ok := node.CachedQuery[hashedWebsite]
-	if ok {
-		log.Info().Msg("Retrieving from LRUCache")
-		for _, ip_c := range ip_addr.value {
-			log.Info().Msgf("> %s. IN A %s", website, ip_c)
-		}
-	} else {
-		ip_addr, ok := node.HashIPStorage[node.Nodeid][hashedWebsite]
-		log.Info().Msgf("> The Website %s has been hashed to %d", website, hashedWebsite)
-		if ok {
-			log.Info().Msg("Retrieving from Local Storage")
-			for _, ip_c := range ip_addr {
-				log.Info().Msgf("> %s. IN A %s", website, ip_c)
-			}
-		} else {
-			succPointer, hopCount := node.FindSuccessor(hashedWebsite, 0)
-			log.Info().Msgf("> Number of Hops: %d", hopCount)
-			// log hopcount into the log file using the library
-			log.Info().Msgf("> The Website would be stored at it's succesor Nodeid: %d IP: %s", succPointer.Nodeid, succPointer.IP)
-			msg := message.RequestMessage{Type: GET, TargetId: hashedWebsite}
-			reply := node.CallRPC(msg, succPointer.IP)
-			if reply.QueryResponse != nil {
-				log.Info().Msg("Retrieving from Chord Network")
-				for _, ip_c := range reply.QueryResponse {
-					log.Info().Msgf("> %s. IN A %s", website, ip_c)
-				}
-			} else {
-				ips, err := net.LookupIP(website)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not get IPs")
-					return
-				}
-				ip_addresses := []string{}
-				log.Info().Msgf("IP ADDRESSES %v", ip_addresses)
-
-				for _, ip := range ips {
-					ip_addresses = append(ip_addresses, ip.String())
-					log.Info().Msgf("> %s. IN A %s", website, ip.String())
-				}
-				node.CachedQuery[hashedWebsite] = LRUCache{value: ip_addresses, cacheTime: node.CacheTime}
-				reply = node.CallRPC(message.RequestMessage{Type: PUT, TargetId: succPointer.Nodeid, Payload: map[uint64][]string{hashedWebsite: ip_addresses}}, succPointer.IP)
-
-				if reply.Type == ACK {
-					// finding the oldest one based on counter, and removing that key
-					if len(node.CachedQuery) > CACHE_SIZE {
-						var minKey uint64
-						minValue := uint64(18446744073709551615)
-						for key, value := range node.CachedQuery {
-							if value.cacheTime < minValue {
-								minKey = key
-								minValue = value.cacheTime
-							}
-						}
-						if minKey != 0 {
-							delete(node.CachedQuery, minKey)
-						}
-					}
-				} else {
-					log.Error().Msg("Put failed")
-				}
-			}
+	log.Debug().Msgf("RECORDS %v", records)
+	result.Source = SOURCE_LEGACY
+	node.queryCache().Put(hashedWebsite, CacheEntry{value: rrset, owner: succPointer})
+	if err := node.QuorumPut(succPointer, hashedWebsite, rrset); err != nil {
+		log.Error().Err(err).Msg("Put failed")
+	}
+	return answerFrom(result, rrset, now)
+}
+
+/*
+Returns the query cache, creating one of DEFAULT_CACHE_SIZE entries if none was given.
+*/
+func (node *Node) queryCache() *cache.LRU[ring.ID, CacheEntry] {
+	node.cacheOnce.Do(func() {
+		if node.CachedQuery == nil {
+			node.CachedQuery = cache.NewLRU[ring.ID, CacheEntry](DEFAULT_CACHE_SIZE)
 		}
+	})
+	return node.CachedQuery
+}
 
+/*
+Fills in the records of result from rrset, with their remaining TTL. Returns ErrNameNotFound if rrset
+caches an NXDOMAIN answer.
+*/
+func answerFrom(result QueryResult, rrset message.RRSet, now time.Time) (QueryResult, error) {
+	result.Records, result.Authority = rrset.Remaining(now)
+	if rrset.Rcode == dns.RcodeNameError {
+		return result, &QueryError{Website: result.Website, Err: ErrNameNotFound}
 	}
+	return result, nil
+}
+
+/*
+Adapts QueryDNS to the dns.Resolver interface, so that the node can back a DNS server.
+*/
+func (node *Node) Resolve(website string, rrtype uint16) ([]dns.RR, []dns.RR, error) {
+	result, err := node.QueryDNS(website, rrtype)
+	return result.Records, result.Authority, err
 }
 
 /*
 Upon receiving a PUT message, or signal, it will simply
- 1. Put the entry into local storage
+ 1. Put the entry into local storage, unless it holds a newer version
  2. Call node.replicate(payload)
 */
-func (node *Node) PutQuery(succesorId uint64, payload map[uint64][]string) bool {
+func (node *Node) PutQuery(succesorId ring.ID, payload map[ring.ID]message.RRSet) bool {
 	//systemcommsin.Println("Recieving a request to insert values into storage")
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
 	if node.HashIPStorage == nil {
-		node.HashIPStorage = make(map[uint64]map[uint64][]string)
+		node.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet)
 	}
 	_, ok := node.HashIPStorage[succesorId]
 	if !ok {
-		node.HashIPStorage[succesorId] = map[uint64][]string{}
+		node.HashIPStorage[succesorId] = map[ring.ID]message.RRSet{}
 	}
+	now := node.now()
 	for key, ip_cache := range payload {
-		node.HashIPStorage[succesorId][key] = ip_cache
+		if ip_cache.Expired(now) {
+			continue
+		}
+		node.store(succesorId, key, ip_cache)
 	}
 
 	return true
 }
 
 /*
-Replicate is called periodically to replicate all the storage entries to a new node.
-Replicated data is only sent to "REPLICATION_FACTOR" nodes
+Replicate is called periodically to bring the replicas of our keys in sync with them, by anti-entropy.
+Replicas are only kept on "ReplicationFactor" nodes
 */
 func (node *Node) replicate() {
-	for {
-		time.Sleep(5 * time.Second)
-		replicationSuccessor := make([]Pointer, REPLICATION_FACTOR)
-		replicationSuccessor = append(replicationSuccessor, node.Successor)
-
-		for i := 0; i < REPLICATION_FACTOR-1; i++ {
-			succesor, _ := node.FindSuccessor(replicationSuccessor[len(replicationSuccessor)-1].Nodeid, 0)
-			replicationSuccessor = append(replicationSuccessor, succesor)
-		}
+	for node.wait(5 * time.Second) {
+		node.replicateOnce()
+	}
+}
 
-		for _, pointer := range replicationSuccessor {
-			if (pointer.IP == node.IP || pointer == Pointer{}) {
-				continue
-			}
-			msg := message.RequestMessage{Type: REPLICATE, TargetId: node.Nodeid, Payload: node.HashIPStorage[node.Nodeid]}
-			node.CallRPC(msg, pointer.IP)
+func (node *Node) replicateOnce() {
+	holders := node.replicaHolders()
+	if len(holders) == 0 {
+		return
+	}
+	tree, size := node.merkleTree(node.Nodeid)
+	for _, pointer := range holders {
+		node.syncWith(pointer, tree, size)
+	}
+}
+
+/*
+Returns the successors that hold a replica of our keys, see pickReplicaHolders.
+*/
+func (node *Node) replicaHolders() []Pointer {
+	return pickReplicaHolders(node.IP, node.succList(), node.replicationFactor())
+}
+
+/*
+Returns the nodes that hold a replica of the keys of the node at addr, given its successor list: the first n
+ones that run in another process than it and than each other, since the virtual nodes of a process fail
+together.
+*/
+func pickReplicaHolders(addr string, succList []Pointer, n int) []Pointer {
+	process, _ := splitVirtual(addr)
+	seen := map[string]bool{process: true}
+	var holders []Pointer
+	for _, pointer := range succList {
+		process, _ := splitVirtual(pointer.IP)
+		if (pointer == Pointer{}) || seen[process] {
+			continue
+		}
+		seen[process] = true
+		holders = append(holders, pointer)
+		if len(holders) == n {
+			break
 		}
 	}
+	return holders
 }
 
 /*
 Processes the REPLICATE Type message received.
 1. If the node's entry is not there, then dump the entire payload there, as it is the only entry.
-2. If the node's entry already exists, then add the new keys to it
+2. If the node's entry already exists, then add the new keys to it, keeping the newer versions it holds
+3. Remove the keys in drop, unless their version changed since the sender saw them
 */
-func (node *Node) processReplicate(senderId uint64, payload map[uint64][]string) bool {
+func (node *Node) processReplicate(senderId ring.ID, payload map[ring.ID]message.RRSet, drop map[ring.ID]message.Version) bool {
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
 	if node.HashIPStorage == nil {
-		node.HashIPStorage = make(map[uint64]map[uint64][]string)
+		node.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet)
 	}
 
-	innerMap, ok := node.HashIPStorage[senderId]
+	_, ok := node.HashIPStorage[senderId]
 	if !ok {
-		innerMap = make(map[uint64][]string)
-		node.HashIPStorage[senderId] = innerMap
+		node.HashIPStorage[senderId] = make(map[ring.ID]message.RRSet)
 	}
 
+	now := node.now()
 	for key, ip_cache := range payload {
-		innerMap[key] = ip_cache
+		if ip_cache.Expired(now) {
+			continue
+		}
+		node.store(senderId, key, ip_cache)
+	}
+	for key, version := range drop {
+		if rrset, ok := node.HashIPStorage[senderId][key]; ok && rrset.Version == version {
+			node.unstore(senderId, key)
+		}
 	}
 
 	return true
 }
 
 /*
-Given a hashed website name, return the records associated with it if it exists, else return nil.
+Stores rrset under key among the keys of owner, unless they hold a newer or the same version that has not
+expired, so that copies arriving late or from stale replicas never replace the writes that followed them. Must
+be called with the storage lock held.
 */
-func (node *Node) GetQuery(hashedId uint64) []string { // unused
-	ip_addr, ok := node.HashIPStorage[node.Nodeid][hashedId]
+func (node *Node) store(owner, key ring.ID, rrset message.RRSet) {
+	node.clock.Observe(rrset.Version)
+	if node.HashIPStorage == nil {
+		node.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet)
+	}
+	storage, ok := node.HashIPStorage[owner]
+	if !ok {
+		storage = make(map[ring.ID]message.RRSet)
+		node.HashIPStorage[owner] = storage
+	}
+	current, ok := storage[key]
+	if ok && !current.Expired(node.now()) && !rrset.Newer(current) {
+		return
+	}
 	if ok {
-		return ip_addr
+		node.track(owner, key, current, false)
+	}
+	storage[key] = rrset
+	node.track(owner, key, rrset, true)
+}
+
+/*
+Removes key from the keys of owner. Must be called with the storage lock held.
+*/
+func (node *Node) unstore(owner, key ring.ID) {
+	if rrset, ok := node.HashIPStorage[owner][key]; ok {
+		delete(node.HashIPStorage[owner], key)
+		node.track(owner, key, rrset, false)
+	}
+}
+
+/*
+Removes every key of owner. Must be called with the storage lock held.
+*/
+func (node *Node) unstoreAll(owner ring.ID) {
+	delete(node.HashIPStorage, owner)
+	delete(node.trees, owner)
+}
+
+/*
+Given the hash of an RRSet key, return the RRSet if it exists and has not expired, else return nil.
+*/
+func (node *Node) GetQuery(hashedId ring.ID) *message.RRSet {
+	node.storageLock().RLock()
+	rrset, ok := node.HashIPStorage[node.Nodeid][hashedId]
+	node.storageLock().RUnlock()
+	if ok && !rrset.Expired(node.now()) {
+		return &rrset
 	} else {
 		return nil
 	}
 }
 
 /*
-Called when a SHIFT message is received. This means that there are new nodes in the network. The node will
-ask you to handover all the entries that falls between you and it. This method helps process this logic.
+Runs periodically to evict expired RRSets from the storage (including replicas held for other nodes)
+and from the cache.
 */
-func (node *Node) GetShiftRecords(prececId uint64) map[uint64][]string {
-	returnPayload := make(map[uint64][]string)
-	nodeStorage, ok := node.HashIPStorage[node.Nodeid]
-	if ok {
-		for hashedWebsite := range nodeStorage {
-			if prececId >= hashedWebsite {
-				returnPayload[hashedWebsite] = nodeStorage[hashedWebsite]
-				delete(nodeStorage, hashedWebsite)
+func (node *Node) sweepExpired() {
+	for node.wait(10 * time.Second) {
+		node.sweepExpiredOnce(node.now())
+	}
+}
+
+func (node *Node) sweepExpiredOnce(now time.Time) {
+	evicted := 0
+	node.storageLock().Lock()
+	for owner, storage := range node.HashIPStorage {
+		for key, rrset := range storage {
+			if rrset.Expired(now) {
+				node.unstore(owner, key)
+				evicted++
 			}
 		}
-		return returnPayload
-	} else {
-		return nil
 	}
+	node.storageLock().Unlock()
+	evicted += node.queryCache().DeleteFunc(func(_ ring.ID, entry CacheEntry) bool {
+		return entry.value.Expired(now)
+	})
+	if evicted > 0 {
+		log.Debug().Msgf("Evicted %d expired entries", evicted)
+	}
+}
+
+/*
+Called when a SHIFT message is received. This means that a new node joined the network as our predecessor, and
+takes over the keys in (predecessor, newNodeId]. The keys are returned along with the replicas we hold for other
+nodes, which the new node should now hold as well since it precedes us.
+
+The keys are not deleted but kept as a replica of the new node, as we are its successor. This makes a retried
+SHIFT return the same keys, and nothing is lost if the reply does not make it to the new node.
+*/
+func (node *Node) GetShiftRecords(newNodeId ring.ID) (map[ring.ID]message.RRSet, map[ring.ID]map[ring.ID]message.RRSet) {
+	// The new node may already be our predecessor if this SHIFT is retried, in which case its range ends
+	// where ours starts.
+	inRange := func(key ring.ID) bool { return !belongsTo(key, newNodeId, node.Nodeid) }
+	if predecessor := node.predecessor(); (predecessor != Pointer{} && predecessor.Nodeid != newNodeId) {
+		if !between(newNodeId, predecessor.Nodeid, node.Nodeid) {
+			// The new node does not precede us: it was sent to us by a node that did not know the ring around
+			// it yet. Its actual successor hands off its keys once it is notified by it.
+			return map[ring.ID]message.RRSet{}, map[ring.ID]map[ring.ID]message.RRSet{}
+		}
+		inRange = func(key ring.ID) bool { return belongsTo(key, predecessor.Nodeid, newNodeId) }
+	}
+
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
+	if node.HashIPStorage == nil {
+		node.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet)
+	}
+
+	shifted := make(map[ring.ID]message.RRSet)
+	for hashedWebsite, rrset := range node.HashIPStorage[node.Nodeid] {
+		if inRange(hashedWebsite) {
+			shifted[hashedWebsite] = rrset
+			node.unstore(node.Nodeid, hashedWebsite)
+		}
+	}
+	if _, ok := node.HashIPStorage[newNodeId]; !ok {
+		node.HashIPStorage[newNodeId] = make(map[ring.ID]message.RRSet)
+	}
+	for hashedWebsite, rrset := range shifted {
+		node.store(newNodeId, hashedWebsite, rrset)
+	}
+	for hashedWebsite, rrset := range node.HashIPStorage[newNodeId] {
+		shifted[hashedWebsite] = rrset
+	}
+
+	// Copies, as the reply is encoded after the lock is released
+	replicas := make(map[ring.ID]map[ring.ID]message.RRSet)
+	for id, storage := range node.HashIPStorage {
+		if id != newNodeId && !node.hosted(id) {
+			replicas[id] = make(map[ring.ID]message.RRSet, len(storage))
+			for key, rrset := range storage {
+				replicas[id][key] = rrset
+			}
+		}
+	}
+	return shifted, replicas
+}
+
+/*
+Returns a copy of the RRSets stored for the node with the given ID: its own keys, or the replica of the keys
+of another node. Used by simulations to inspect the storage while the node keeps running.
+*/
+func (node *Node) Storage(id ring.ID) map[ring.ID]message.RRSet {
+	return node.storageCopy(id)
+}
+
+/*
+Returns a copy of the RRSets stored for the node with the given ID, which can be sent in a message while the
+storage keeps changing.
+*/
+func (node *Node) storageCopy(id ring.ID) map[ring.ID]message.RRSet {
+	node.storageLock().RLock()
+	defer node.storageLock().RUnlock()
+	storage := make(map[ring.ID]message.RRSet, len(node.HashIPStorage[id]))
+	for key, rrset := range node.HashIPStorage[id] {
+		storage[key] = rrset
+	}
+	return storage
+}
+
+/*
+Drops the replicas held for the node with the given ID.
+*/
+func (node *Node) dropReplicas(id ring.ID) {
+	node.storageLock().Lock()
+	defer node.storageLock().Unlock()
+	node.unstoreAll(id)
 }
 
 /*
 Write the entry to persistent storage within the container.
-It opens file in write or (create and write) mode.
+It writes a temporary file and renames it over the previous one, so that concurrent writes and crashes never
+leave a partially written file behind.
 */
 func (node *Node) writeToStorage() {
-	filePath := fmt.Sprintf("./data/%s.json", node.IP)
-	myStorage := node.HashIPStorage
-	jsonData, err := json.Marshal(myStorage)
+	if err := os.MkdirAll(node.dataDir(), 0755); err != nil {
+		log.Error().Err(err).Msg("Error creating the data directory")
+		return
+	}
+	filePath := node.storageFile()
+	node.storageLock().RLock()
+	jsonData, err := json.Marshal(node.HashIPStorage)
+	node.storageLock().RUnlock()
 	if err != nil {
 		log.Error().Err(err).Msg("Error marshalling the JSON data")
 		return
 	}
 	log.Debug().Msgf("JSON data: %s", jsonData)
-	// Write to the file, create it if it doesn't exist
-	// Append to the file or create it if it doesn't exist
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, 0666)
+	file, err := os.CreateTemp(node.dataDir(), filepath.Base(filePath)+".*")
 	if err != nil {
-		log.Error().Err(err).Msg("Error opening or creating the file")
+		log.Error().Err(err).Msg("Error creating the file")
 		return
 	}
+	defer os.Remove(file.Name())
 
 	// Write the content to the file
 	_, err = file.Write(jsonData)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Error writing to the file")
 		return
 	}
+	if err = os.Rename(file.Name(), filePath); err != nil {
+		log.Error().Err(err).Msg("Error replacing the file")
+		return
+	}
 	log.Debug().Msgf("JSON data written to file: %s", filePath)
-	defer file.Close()
 }
 
 /*
@@ -253,7 +523,7 @@ Reads file from local container storage.
 It opens file in read or (create and read) mode.
 */
 func (node *Node) readFromStorage() {
-	filePath := fmt.Sprintf("./data/%s.json", node.IP)
+	filePath := node.storageFile()
 
 	// Open the file for reading
 	file, err := os.OpenFile(filePath, os.O_RDONLY|os.O_CREATE, 0666)
@@ -262,7 +532,7 @@ func (node *Node) readFromStorage() {
 		return
 	}
 	defer file.Close()
-	var storage map[uint64]map[uint64][]string
+	var storage map[ring.ID]map[ring.ID]message.RRSet
 	decoder := json.NewDecoder(file)
 	err = decoder.Decode(&storage)
 	if err != nil {
@@ -275,5 +545,24 @@ func (node *Node) readFromStorage() {
 	for key, value := range storage {
 		log.Debug().Msgf("Key: %v, Value: %v\n", key, value)
 	}
-	node.HashIPStorage = storage
+	node.storageLock().Lock()
+	// Unless keys were stored while reading. The map may be shared with other virtual nodes, so it is filled in
+	// rather than replaced.
+	if node.HashIPStorage == nil {
+		node.HashIPStorage = make(map[ring.ID]map[ring.ID]message.RRSet, len(storage))
+	}
+	if len(node.HashIPStorage) == 0 {
+		for id, rrsets := range storage {
+			node.HashIPStorage[id] = rrsets
+		}
+	}
+	node.storageLock().Unlock()
+}
+
+/*
+Returns the file the storage is persisted to, which is the same for the virtual nodes of a process.
+*/
+func (node *Node) storageFile() string {
+	process, _ := splitVirtual(node.IP)
+	return filepath.Join(node.dataDir(), process+".json")
 }
@@ -0,0 +1,61 @@
+package node_test
+
+import (
+	"flag"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/stress"
+	"github.com/fauzxan/dns-chord/v2/transport"
+	"github.com/rs/zerolog"
+)
+
+var duration = flag.Duration("duration", 10*time.Second, "how long TestStress sends queries, 6s with -short")
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+/*
+Hammers a ring on the in-memory transport with joins, a leave, queries and replication. Run it with -race,
+which fails the test if the nodes race:
+
+	go test -race ./node -run Stress -duration 30s
+*/
+func TestStress(t *testing.T) {
+	d := *duration
+	if testing.Short() {
+		d = 6 * time.Second
+	}
+	network := transport.NewMemoryNetwork()
+	result, err := stress.Run(stress.Config{
+		Nodes:     8,
+		VNodes:    2,
+		Duration:  d,
+		Upstream:  freeAddr(t),
+		Transport: func(int) transport.Transport { return network.Transport() },
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Queries == 0 {
+		t.Fatal("no query was sent")
+	}
+	if result.Failures > 0 {
+		t.Errorf("%d of %d queries failed", result.Failures, result.Queries)
+	}
+	t.Logf("%d queries, %d anti-entropy rounds, %d read repairs", result.Queries, result.Sync.Rounds, result.Repairs.Repairs)
+}
+
+// Returns a loopback address whose port is free for the stub upstream, on UDP and likely on TCP too.
+func freeAddr(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().String()
+}
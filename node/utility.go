@@ -1,9 +1,16 @@
 package node
 
 import (
-	"net/rpc"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/fauzxan/dns-chord/v2/dns"
 	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/fauzxan/dns-chord/v2/transport"
 	"github.com/rs/zerolog/log"
 )
 
@@ -14,36 +21,42 @@ import (
 */
 
 /*
-Node utility function to call RPC given a request message, and a destination IP address.
+Node utility function to call RPC given a request message, and a destination IP address. The call is bounded
+by the node's RPC timeout. On failure, the reply has type EMPTY and the error tells whether the peer could not
+be reached (ErrPeerUnreachable, which includes timeouts) or handled the message and returned an error
+(*RemoteError).
 */
-func (node *Node) CallRPC(msg message.RequestMessage, IP string) message.ResponseMessage {
-	log.Debug().Msgf("Nodeid: %d IP: %s is sending message %v to IP: %s", node.Nodeid, node.IP, msg, IP)
-	clnt, err := rpc.Dial("tcp", IP)
-	reply := message.ResponseMessage{}
+func (node *Node) CallRPC(msg message.RequestMessage, IP string) (message.ResponseMessage, error) {
+	return node.CallRPCContext(context.Background(), msg, IP)
+}
+
+/*
+Same as CallRPC, but also gives up when ctx is done.
+*/
+func (node *Node) CallRPCContext(ctx context.Context, msg message.RequestMessage, IP string) (message.ResponseMessage, error) {
+	log.Debug().Msgf("Nodeid: %s IP: %s is sending message %v to IP: %s", node.Nodeid, node.IP, msg, IP)
+	ctx, cancel := context.WithTimeout(ctx, node.rpcTimeout())
+	defer cancel()
+	msg.Receiver = IP
+	process, _ := splitVirtual(IP)
+	reply, err := node.transportLayer().Call(ctx, process, msg)
 	if err != nil {
 		log.Error().Err(err).Msg(msg.Type)
-		log.Debug().Msgf("Nodeid: %d IP: %s received reply %v from IP: %s", node.Nodeid, node.IP, reply, IP)
-		reply.Type = EMPTY
-		return reply
+		return message.ResponseMessage{Type: EMPTY}, err
 	}
-	err = clnt.Call("Node.HandleIncomingMessage", msg, &reply)
-	if err != nil {
-		log.Error().Err(err).Msg("Error calling RPC")
-		log.Debug().Msgf("Nodeid: %d IP: %s received reply %v from IP: %s", node.Nodeid, node.IP, reply, IP)
-		reply.Type = EMPTY
-		return reply
-	}
-	log.Debug().Msgf("Received reply from %s", IP)
-	return reply
+	log.Debug().Msgf("Nodeid: %s IP: %s received reply %v from IP: %s", node.Nodeid, node.IP, reply, IP)
+	return reply, nil
 }
 
 /*
 Node utility function to print fingers
 */
 func (node *Node) PrintFingers() {
+	node.ringMu.RLock()
+	defer node.ringMu.RUnlock()
 	log.Info().Msg("Finger Table:")
 	for i := 0; i < len(node.FingerTable); i++ {
-		log.Info().Msgf("> Finger[%d]: Nodeid: %d IP: %s", i+1, node.FingerTable[i].Nodeid, node.FingerTable[i].IP)
+		log.Info().Msgf("> Finger[%d]: Nodeid: %s IP: %s", i+1, node.FingerTable[i].Nodeid, node.FingerTable[i].IP)
 	}
 }
 
@@ -51,60 +64,267 @@ func (node *Node) PrintFingers() {
 Node utility function to print the successor
 */
 func (node *Node) PrintSuccessor() {
+	successor := node.successor()
 	log.Info().Msg("Successor:")
-	log.Info().Msgf(">Nodeid: %d Successor.IP: %s", node.Successor.Nodeid, node.Successor.IP)
+	log.Info().Msgf(">Nodeid: %s Successor.IP: %s", successor.Nodeid, successor.IP)
 }
 
 /*
 Node utility function to print predecessor
 */
 func (node *Node) PrintPredecessor() {
+	predecessor := node.predecessor()
 	log.Info().Msg("Predecessor:")
-	log.Info().Msgf(">Nodeid: %d Predecessor.IP: %s", node.Predecessor.Nodeid, node.Predecessor.IP)
+	log.Info().Msgf(">Nodeid: %s Predecessor.IP: %s", predecessor.Nodeid, predecessor.IP)
 }
 
 func (node *Node) PrintStorage() {
+	node.storageLock().RLock()
+	defer node.storageLock().RUnlock()
 	log.Info().Msg("STORAGE TABLE REQUESTED")
 	log.Info().Msg("Storage:")
 	for id, storage := range node.HashIPStorage {
-		log.Info().Msgf(">id: %d", id)
-		for _, value := range storage {
-			log.Info().Msgf(">>value: %s", value)
+		log.Info().Msgf(">id: %s", id)
+		for _, rrset := range storage {
+			printNegative(rrset)
+			for _, rr := range rrset.Records {
+				log.Info().Msgf(">>value: %s", rr)
+			}
 		}
 	}
+	stats := node.SyncStats()
+	log.Info().Msgf("Anti-entropy rounds: %d In sync: %d Keys sent: %d dropped: %d taken: %d Bytes sent: %d saved: %d",
+		stats.Rounds, stats.InSync, stats.KeysSent, stats.KeysDropped, stats.KeysTaken, stats.BytesSent, stats.BytesSaved())
+	repairs := node.RepairStats()
+	log.Info().Msgf("Read repair reads: %d Divergent: %d Repairs: %d Failed: %d", repairs.Reads, repairs.Divergent, repairs.Repairs, repairs.Failed)
+	hints := node.HintStats()
+	log.Info().Msgf("Hints pending: %d Stored: %d Delivered: %d Dropped: %d Expired: %d", node.PendingHints(), hints.Stored, hints.Delivered, hints.Dropped, hints.Expired)
 }
 
 func (node *Node) PrintCache() {
 	log.Info().Msg("CACHE TABLE REQUESTED")
-	for id, cache := range node.CachedQuery {
-		log.Info().Msgf(">id: %d value: %s", id, cache.value)
+	queryCache := node.queryCache()
+	// Most recently used first
+	queryCache.Range(func(id ring.ID, cache CacheEntry) {
+		log.Info().Msgf(">id: %s name: %s type: %s", id, cache.value.Name, dns.TypeString(cache.value.Type))
+		printNegative(cache.value)
+		for _, rr := range cache.value.Records {
+			log.Info().Msgf(">>value: %s", rr)
+		}
+	})
+	stats := queryCache.Stats()
+	log.Info().Msgf("Entries: %d/%d Hits: %d Misses: %d Evictions: %d", queryCache.Len(), queryCache.Capacity(), stats.Hits, stats.Misses, stats.Evictions)
+}
+
+/*
+Node utility function to get the configured successor list length, or the default one
+*/
+func (node *Node) succListLength() int {
+	if node.SuccListLength > 0 {
+		return node.SuccListLength
 	}
+	return DEFAULT_SUCC_LIST_LENGTH
 }
 
 /*
-Node utility function to check if an ID is in a given range (a, b].
+Node utility function to get the configured read quorum, or the default one
+*/
+func (node *Node) readQuorum() int {
+	if node.ReadQuorum > 0 {
+		return node.ReadQuorum
+	}
+	return DEFAULT_READ_QUORUM
+}
+
+/*
+Node utility function to get the configured bound on hints, or the default one
+*/
+func (node *Node) maxHints() int {
+	if node.MaxHints > 0 {
+		return node.MaxHints
+	}
+	return DEFAULT_MAX_HINTS
+}
+
+/*
+Node utility function to get the configured lifetime of hints, or the default one
+*/
+func (node *Node) hintTTL() time.Duration {
+	if node.HintTTL > 0 {
+		return node.HintTTL
+	}
+	return DEFAULT_HINT_TTL
+}
+
+/*
+Node utility function to get the configured write quorum, or the default one
+*/
+func (node *Node) writeQuorum() int {
+	if node.WriteQuorum > 0 {
+		return node.WriteQuorum
+	}
+	return DEFAULT_WRITE_QUORUM
+}
+
+/*
+Node utility function to get the configured replication factor, or the default one
+*/
+func (node *Node) replicationFactor() int {
+	if node.ReplicationFactor > 0 {
+		return node.ReplicationFactor
+	}
+	return DEFAULT_REPLICATION_FACTOR
+}
+
+/*
+Node utility function to get the configured RPC timeout, or the default one
+*/
+func (node *Node) rpcTimeout() time.Duration {
+	if node.RPCTimeout > 0 {
+		return node.RPCTimeout
+	}
+	return DEFAULT_RPC_TIMEOUT
+}
+
+/*
+Node utility function to get the current time from the configured clock, or the system one
+*/
+func (node *Node) now() time.Time {
+	if node.Now != nil {
+		return node.Now()
+	}
+	return time.Now()
+}
+
+/*
+Node utility function to get the lock of the storage, which virtual nodes share with their host
+*/
+func (node *Node) storageLock() *sync.RWMutex {
+	node.storageOnce.Do(func() {
+		if node.storageMu == nil {
+			node.storageMu = new(sync.RWMutex)
+		}
+	})
+	return node.storageMu
+}
+
+/*
+Returns the address of virtual node k of the process at addr, see Host. Virtual node 0 is at addr itself.
 */
-func belongsTo(id, a, b uint64) bool {
-	if a == b {
-		return true
+func VirtualAddress(addr string, k int) string {
+	if k == 0 {
+		return addr
 	}
-	if a < b {
-		return a < id && id <= b
-	} else {
-		return a < id || id <= b
+	return addr + "#" + strconv.Itoa(k)
+}
+
+/*
+Splits the address of a virtual node into the address of its process, where messages are sent, and its index.
+*/
+func splitVirtual(addr string) (string, int) {
+	i := strings.LastIndexByte(addr, '#')
+	if i < 0 {
+		return addr, 0
 	}
+	k, err := strconv.Atoi(addr[i+1:])
+	if err != nil || k < 0 {
+		return addr, 0
+	}
+	return addr[:i], k
+}
+
+/*
+Node utility function to get the configured transport, or a gRPC one
+*/
+func (node *Node) transportLayer() transport.Transport {
+	node.transportOnce.Do(func() {
+		if node.Transport == nil {
+			node.Transport = transport.NewGRPC(RPC_IDLE_TIMEOUT)
+		}
+	})
+	return node.Transport
+}
+
+/*
+Node utility function to get the configured data directory, or the default one
+*/
+func (node *Node) dataDir() string {
+	if node.DataDir != "" {
+		return node.DataDir
+	}
+	return DEFAULT_DATA_DIR
+}
+
+/*
+Node utility function to check if an ID is in a given range (a, b].
+*/
+func belongsTo(id, a, b ring.ID) bool {
+	return ring.BelongsTo(id, a, b)
 }
 
 /*
 Node utility function to check if an ID is in a given range (a, b).
 */
-func between(id, a, b uint64) bool {
-	if a == b {
-		return true
+func between(id, a, b ring.ID) bool {
+	return ring.Between(id, a, b)
+}
+
+/*
+Node utility function to get the configured ID width, or the default one
+*/
+func (node *Node) idBits() int {
+	if node.IDBits > 0 {
+		return node.IDBits
+	}
+	return DEFAULT_ID_BITS
+}
+
+/*
+Node utility function to hash a key, e.g. the RRSet key of a website, to its ID on the ring
+*/
+func (node *Node) hash(key string) ring.ID {
+	return ring.Hash(key, node.idBits())
+}
+
+/*
+Node utility function to print the kind of negative answer an RRSet caches, if any
+*/
+func printNegative(rrset message.RRSet) {
+	if rrset.Rcode == dns.RcodeNameError {
+		log.Info().Msgf(">>negative: %s NXDOMAIN", rrset.Name)
+	} else if rrset.Negative() {
+		log.Info().Msgf(">>negative: %s %s NODATA", rrset.Name, dns.TypeString(rrset.Type))
+	}
+}
+
+/*
+Node utility function to print the outcome of QueryDNS
+*/
+func PrintQueryResult(result QueryResult, err error) {
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get IPs")
+		return
+	}
+	log.Info().Msgf("> Source: %s Number of Hops: %d", result.Source, result.HopCount)
+	log.Info().Msgf("> Stored at Nodeid: %s IP: %s", result.Owner.Nodeid, result.Owner.IP)
+	if len(result.Records) == 0 {
+		log.Info().Msgf("> %s. has no %s records", result.Website, dns.TypeString(result.Type))
+	}
+	for _, rr := range result.Records {
+		log.Info().Msgf("> %s", rr)
+	}
+}
+
+/*
+Node utility function to print the health of the upstream resolvers
+*/
+func (node *Node) PrintUpstreams() {
+	if node.Upstream == nil {
+		log.Info().Msg("No upstream resolvers configured, using the host's resolver")
+		return
 	}
-	if a < b {
-		return a < id && id < b
-	} else {
-		return a < id || id < b
+	for _, health := range node.Upstream.Health() {
+		log.Info().Msgf("> %s down: %t queries: %d failures: %d last rtt: %s last error: %s",
+			health.Addr, health.Down, health.Queries, health.Failures, health.LastRTT, health.LastError)
 	}
 }
@@ -0,0 +1,164 @@
+/*
+Identifiers of nodes and keys on the chord ring. IDs are integers of up to MAX_BITS bits, stored exactly as
+big-endian byte arrays, so that all the arithmetic on them is exact whatever the width of the ring. Nodes of a
+network must all use the same width.
+*/
+package ring
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const MAX_BITS = 256
+
+/*
+Position on a ring of up to 2^MAX_BITS IDs: an unsigned integer stored big-endian. IDs are comparable, so they
+can be used as map keys, and marshal to hexadecimal text, also as JSON map keys.
+*/
+type ID [MAX_BITS / 8]byte
+
+/*
+Returns the ID of data on a ring of 2^bits IDs: the SHA-256 digest of data, modulo 2^bits.
+*/
+func Hash(data string, bits int) ID {
+	return ID(sha256.Sum256([]byte(data))).Mod(bits)
+}
+
+/*
+Returns an error unless bits is a valid width of a ring, between 1 and MAX_BITS.
+*/
+func CheckBits(bits int) error {
+	if bits < 1 || bits > MAX_BITS {
+		return fmt.Errorf("ID width must be between 1 and %d bits, got %d", MAX_BITS, bits)
+	}
+	return nil
+}
+
+/*
+Returns the ID of a big-endian integer, as returned by Bytes. Bytes beyond the width of an ID are an error.
+*/
+func FromBytes(b []byte) (ID, error) {
+	var id ID
+	if len(b) > len(id) {
+		return id, fmt.Errorf("ID of %d bytes is longer than %d bytes", len(b), len(id))
+	}
+	copy(id[len(id)-len(b):], b)
+	return id, nil
+}
+
+/*
+Returns the ID as a big-endian integer without leading zero bytes, empty for the zero ID.
+*/
+func (id ID) Bytes() []byte {
+	i := 0
+	for i < len(id) && id[i] == 0 {
+		i++
+	}
+	return append([]byte(nil), id[i:]...)
+}
+
+func (id ID) IsZero() bool {
+	return id == ID{}
+}
+
+/*
+Returns -1, 0 or 1 if id is smaller than, equal to or greater than other.
+*/
+func (id ID) Cmp(other ID) int {
+	return bytes.Compare(id[:], other[:])
+}
+
+/*
+Returns id modulo 2^bits.
+*/
+func (id ID) Mod(bits int) ID {
+	cleared := len(id) - (bits+7)/8 // Bytes entirely above the width
+	for i := 0; i < cleared; i++ {
+		id[i] = 0
+	}
+	if bits%8 != 0 {
+		id[cleared] &= byte(1)<<(bits%8) - 1
+	}
+	return id
+}
+
+/*
+Returns id + 2^i modulo 2^bits, the start of the interval covered by finger i+1 of a node.
+*/
+func (id ID) AddPow2(i, bits int) ID {
+	// Add 1 at bit i, and propagate the carry towards the most significant byte
+	k := len(id) - 1 - i/8
+	carry := uint16(1) << (i % 8)
+	for ; k >= 0 && carry != 0; k-- {
+		sum := uint16(id[k]) + carry
+		id[k] = byte(sum)
+		carry = sum >> 8
+	}
+	return id.Mod(bits)
+}
+
+/*
+Returns true if id is in the interval (a, b] of the ring, which wraps around after the largest ID. The
+interval is the whole ring if a == b.
+*/
+func BelongsTo(id, a, b ID) bool {
+	switch a.Cmp(b) {
+	case 0:
+		return true
+	case -1:
+		return a.Cmp(id) < 0 && id.Cmp(b) <= 0
+	default:
+		return a.Cmp(id) < 0 || id.Cmp(b) <= 0
+	}
+}
+
+/*
+Returns true if id is in the interval (a, b) of the ring, which wraps around after the largest ID. The
+interval is the whole ring if a == b.
+*/
+func Between(id, a, b ID) bool {
+	switch a.Cmp(b) {
+	case 0:
+		return true
+	case -1:
+		return a.Cmp(id) < 0 && id.Cmp(b) < 0
+	default:
+		return a.Cmp(id) < 0 || id.Cmp(b) < 0
+	}
+}
+
+/*
+Returns the ID in hexadecimal, without leading zeros.
+*/
+func (id ID) String() string {
+	s := strings.TrimLeft(hex.EncodeToString(id[:]), "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *ID) UnmarshalText(text []byte) error {
+	s := string(text)
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid ID %q: %w", text, err)
+	}
+	parsed, err := FromBytes(b)
+	if err != nil {
+		return fmt.Errorf("invalid ID %q: %w", text, err)
+	}
+	*id = parsed
+	return nil
+}
@@ -0,0 +1,148 @@
+package sim
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/node"
+	"github.com/fauzxan/dns-chord/v2/ring"
+)
+
+/*
+Returned by Check when the ring is not in the state its alive nodes should converge to. Seed and Step replay
+the simulation up to the failure.
+*/
+type InvariantError struct {
+	Seed       int64
+	Step       int
+	Violations []string
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("seed %d, step %d: %d violated invariants:\n\t%s", e.Seed, e.Step, len(e.Violations), strings.Join(e.Violations, "\n\t"))
+}
+
+/*
+Checks the invariants of a stable ring of the alive nodes:
+  - The successor of each node is the next alive node by ID, and its predecessor the previous one.
+  - The successor list of each node holds the next alive nodes, as many as fit in its length without
+    wrapping around to the node itself.
+  - Finger i of each node is the first alive node whose ID follows the node's ID + 2^i.
+  - The replica holders of each node, its next alive nodes, hold the same versions of the same keys as it.
+  - The Merkle trees each node keeps of its storage match it.
+  - Every key put into the ring can be looked up from any node, and the last version put is returned.
+
+Returns nil if they all hold, or an *InvariantError listing the violations. Lookups go through the network
+and its faults, so keys are only retrievable across a partition once it is healed.
+*/
+func (s *Sim) Check() error {
+	var violations []string
+	members := s.members()
+	if len(members) == 0 {
+		return nil
+	}
+	for k, n := range members {
+		state := n.Ring()
+		next := pointer(members[(k+1)%len(members)])
+		previous := pointer(members[(k+len(members)-1)%len(members)])
+		if state.Successor != next {
+			violations = append(violations, fmt.Sprintf("node %s: successor is %s, want %s", n.Nodeid, state.Successor.Nodeid, next.Nodeid))
+		}
+		if state.Predecessor != previous {
+			violations = append(violations, fmt.Sprintf("node %s: predecessor is %s, want %s", n.Nodeid, state.Predecessor.Nodeid, previous.Nodeid))
+		}
+		want := []node.Pointer{next}
+		for j := k + 2; len(want) < s.succListLength && j < k+len(members); j++ {
+			want = append(want, pointer(members[j%len(members)]))
+		}
+		if !slices.Equal(state.SuccList, want) {
+			violations = append(violations, fmt.Sprintf("node %s: successor list is %s, want %s", n.Nodeid, ids(state.SuccList), ids(want)))
+		}
+		for i, finger := range state.FingerTable {
+			start := n.Nodeid.AddPow2(i, s.idBits)
+			if want := successorOf(members, start); finger != want {
+				violations = append(violations, fmt.Sprintf("node %s: finger %d is %s, want %s", n.Nodeid, i+1, finger.Nodeid, want.Nodeid))
+			}
+		}
+	}
+
+	holders := min(s.replicationFactor, s.succListLength, len(members)-1)
+	for k, n := range members {
+		owned := n.Storage(n.Nodeid)
+		for j := k + 1; j <= k+holders; j++ {
+			holder := members[j%len(members)]
+			if missing, extra, stale := diffStorage(owned, holder.Storage(n.Nodeid)); missing+extra+stale > 0 {
+				violations = append(violations, fmt.Sprintf("node %s: replica on %s lacks %d keys, has %d extra and %d stale", n.Nodeid, holder.Nodeid, missing, extra, stale))
+			}
+		}
+		if err := n.VerifyTrees(); err != nil {
+			violations = append(violations, fmt.Sprintf("node %s: %v", n.Nodeid, err))
+		}
+	}
+
+	keys := make([]ring.ID, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	// Sorted, so that lookups use the random generator in the same order on every run
+	sort.Slice(keys, func(a, b int) bool { return keys[a].Cmp(keys[b]) < 0 })
+	for _, key := range keys {
+		rrset, err := s.Get(key)
+		switch {
+		case err != nil:
+			violations = append(violations, fmt.Sprintf("key %s (%s): %v", key, s.keys[key].Name, err))
+		case rrset == nil:
+			violations = append(violations, fmt.Sprintf("key %s (%s): not found", key, s.keys[key].Name))
+		case !slices.Equal(rrset.Records, s.keys[key].Records):
+			violations = append(violations, fmt.Sprintf("key %s (%s): stale version %v, want %v", key, s.keys[key].Name, rrset.Records, s.keys[key].Records))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &InvariantError{Seed: s.Seed, Step: s.Steps, Violations: violations}
+}
+
+/*
+Compares the replica of a node's keys with them: counts the keys missing from it, the keys it has in excess,
+and the keys whose version differs.
+*/
+func diffStorage(owned, replica map[ring.ID]message.RRSet) (missing, extra, stale int) {
+	for key, rrset := range owned {
+		if held, ok := replica[key]; !ok {
+			missing++
+		} else if held.Version != rrset.Version {
+			stale++
+		}
+	}
+	for key := range replica {
+		if _, ok := owned[key]; !ok {
+			extra++
+		}
+	}
+	return missing, extra, stale
+}
+
+func pointer(n *node.Node) node.Pointer {
+	return node.Pointer{Nodeid: n.Nodeid, IP: n.IP}
+}
+
+func ids(pointers []node.Pointer) string {
+	out := make([]string, len(pointers))
+	for i, p := range pointers {
+		out[i] = p.Nodeid.String()
+	}
+	return "[" + strings.Join(out, " ") + "]"
+}
+
+/*
+Returns the first node of members, sorted by ID, whose ID is id or follows it.
+*/
+func successorOf(members []*node.Node, id ring.ID) node.Pointer {
+	k := sort.Search(len(members), func(k int) bool { return members[k].Nodeid.Cmp(id) >= 0 })
+	return pointer(members[k%len(members)])
+}
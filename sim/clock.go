@@ -0,0 +1,23 @@
+package sim
+
+import "time"
+
+/*
+Fake clock shared by the nodes of a simulation. Time only moves when the simulation advances it: by a second
+on each Step, by the delay of every delayed or timed out message, and by a millisecond on each Put.
+*/
+type Clock struct {
+	now time.Time
+}
+
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+func (c *Clock) Now() time.Time {
+	return c.now
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
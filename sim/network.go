@@ -0,0 +1,205 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/transport"
+)
+
+var (
+	errDown        = errors.New("node is down")
+	errPartitioned = errors.New("partitioned")
+	errDropped     = errors.New("message dropped")
+)
+
+/*
+Faults injected on the messages sent from one node to another. Replies travel on the link in the other
+direction, so a fault on it fails calls whose message was already handled.
+*/
+type Fault struct {
+	Blocked  bool          // Every message is lost, e.g. because of a partition.
+	Delay    time.Duration // Added to each message. Messages delayed past the RPC timeout are lost.
+	DropRate float64       // Probability in [0, 1] that a message is lost.
+}
+
+type link struct {
+	from, to string
+}
+
+/*
+Network of a simulation. Messages are handled synchronously, by the goroutine that sends them, so that a
+simulation runs on a single goroutine and its outcome only depends on its seed. A lost message fails the
+call once the RPC timeout has passed on the clock.
+*/
+type Network struct {
+	clock   *Clock
+	rand    *rand.Rand
+	timeout time.Duration
+
+	handlers map[string]transport.Handler // By listening address.
+	faults   map[link]Fault
+
+	Delivered int // Messages handled, including those whose reply was lost.
+	Lost      int // Messages or replies lost to faults.
+}
+
+func NewNetwork(clock *Clock, rand *rand.Rand, timeout time.Duration) *Network {
+	return &Network{
+		clock:    clock,
+		rand:     rand,
+		timeout:  timeout,
+		handlers: make(map[string]transport.Handler),
+		faults:   make(map[link]Fault),
+	}
+}
+
+/*
+Returns the transport of the node listening at addr, which tells the network who sends each message.
+*/
+func (n *Network) Transport(addr string) transport.Transport {
+	return &endpoint{network: n, addr: addr}
+}
+
+/*
+Sets the faults on the messages sent from one address to another, replacing the previous ones.
+*/
+func (n *Network) SetFault(from, to string, fault Fault) {
+	if fault == (Fault{}) {
+		delete(n.faults, link{from, to})
+		return
+	}
+	n.faults[link{from, to}] = fault
+}
+
+// Removes every fault.
+func (n *Network) Heal() {
+	n.faults = make(map[link]Fault)
+}
+
+/*
+Stops delivering messages to addr, as if the node listening there crashed.
+*/
+func (n *Network) Down(addr string) {
+	delete(n.handlers, addr)
+}
+
+func (n *Network) call(from, to string, msg message.RequestMessage) (message.ResponseMessage, error) {
+	handler, ok := n.handlers[to]
+	if !ok {
+		return message.ResponseMessage{}, unreachable(to, errDown)
+	}
+	elapsed, err := n.cross(from, to, 0)
+	if err != nil {
+		return message.ResponseMessage{}, unreachable(to, err)
+	}
+	// Copied through gob like on the wire, so that nodes never share maps
+	var reply message.ResponseMessage
+	copied, err := clone(msg)
+	if err != nil {
+		return message.ResponseMessage{}, unreachable(to, err)
+	}
+	n.Delivered++
+	if err := handler.HandleIncomingMessage(&copied, &reply); err != nil {
+		return message.ResponseMessage{}, &transport.RemoteError{Peer: to, Message: err.Error()}
+	}
+	if _, err := n.cross(to, from, elapsed); err != nil {
+		return message.ResponseMessage{}, unreachable(to, err)
+	}
+	if reply, err = clone(reply); err != nil {
+		return message.ResponseMessage{}, unreachable(to, err)
+	}
+	return reply, nil
+}
+
+/*
+Applies the faults of the link from one address to another to a message sent elapsed after the call started.
+Advances the clock by the delay of the message, or up to the timeout if it is lost, and returns the time
+elapsed since the call started.
+*/
+func (n *Network) cross(from, to string, elapsed time.Duration) (time.Duration, error) {
+	if from == to {
+		return elapsed, nil
+	}
+	fault := n.faults[link{from, to}]
+	var err error
+	switch {
+	case fault.Blocked:
+		err = errPartitioned
+	case fault.DropRate > 0 && n.rand.Float64() < fault.DropRate:
+		err = errDropped
+	case elapsed+fault.Delay >= n.timeout:
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		n.Lost++
+		n.clock.Advance(n.timeout - elapsed)
+		return n.timeout, err
+	}
+	n.clock.Advance(fault.Delay)
+	return elapsed + fault.Delay, nil
+}
+
+/*
+Transport of one node of a Network.
+*/
+type endpoint struct {
+	network *Network
+	addr    string
+	closed  bool
+}
+
+func (e *endpoint) Call(ctx context.Context, addr string, msg message.RequestMessage) (message.ResponseMessage, error) {
+	if e.closed {
+		return message.ResponseMessage{}, unreachable(addr, transport.ErrClosed)
+	}
+	if err := ctx.Err(); err != nil {
+		return message.ResponseMessage{}, unreachable(addr, err)
+	}
+	return e.network.call(e.addr, addr, msg)
+}
+
+func (e *endpoint) Listen(addr string, handler transport.Handler) error {
+	if e.closed {
+		return transport.ErrClosed
+	}
+	if addr != e.addr {
+		return fmt.Errorf("listen %s: transport belongs to %s", addr, e.addr)
+	}
+	if _, ok := e.network.handlers[addr]; ok {
+		return fmt.Errorf("listen %s: address already in use", addr)
+	}
+	e.network.handlers[addr] = handler
+	return nil
+}
+
+func (e *endpoint) Close() error {
+	if !e.closed {
+		e.closed = true
+		e.network.Down(e.addr)
+	}
+	return nil
+}
+
+func unreachable(addr string, err error) error {
+	return fmt.Errorf("%w: %s: %w", transport.ErrPeerUnreachable, addr, err)
+}
+
+/*
+Returns a deep copy of a message, made by encoding and decoding it with gob.
+*/
+func clone[T any](msg T) (T, error) {
+	var buf bytes.Buffer
+	var copied T
+	if err := gob.NewEncoder(&buf).Encode(msg); err != nil {
+		return copied, err
+	}
+	err := gob.NewDecoder(&buf).Decode(&copied)
+	return copied, err
+}
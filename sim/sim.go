@@ -0,0 +1,348 @@
+/*
+Deterministic simulation of a ring of nodes in one process, for tests and for reproducing failures.
+
+Nodes run on a simulated Network and Clock, and their periodic tasks are run by Step instead of goroutines,
+so a whole simulation runs on the calling goroutine and two runs with the same seed are identical. Nodes can
+be added, killed or made to leave, links between them partitioned, delayed or made lossy, and Check verifies
+the invariants of the ring. From a test:
+
+	s, err := sim.New(sim.Config{Nodes: 8, Seed: seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.PutKeys(100)
+	s.Kill(3)
+	if err := s.Settle(60); err != nil {
+		t.Fatal(err) // Reports the seed, which replays the same run
+	}
+
+The nodes log through zerolog like any other node; tests usually disable it with zerolog.SetGlobalLevel. The
+tests of this package run such a scenario for each kind of fault, see sim_test.go.
+*/
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/cache"
+	"github.com/fauzxan/dns-chord/v2/dns"
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/node"
+	"github.com/fauzxan/dns-chord/v2/ring"
+)
+
+/*
+Time at which simulations start, so that record expiry does not depend on when they run.
+*/
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type Config struct {
+	Nodes             int           // Nodes in the initial ring, at least 1.
+	Seed              int64         // Seed of every random choice: node IDs, which node helps joins, the order nodes run in, and message drops.
+	ReplicationFactor int           // node.DEFAULT_REPLICATION_FACTOR if 0.
+	SuccListLength    int           // node.DEFAULT_SUCC_LIST_LENGTH if 0.
+	RPCTimeout        time.Duration // Simulated time after which a lost message fails. node.DEFAULT_RPC_TIMEOUT if 0.
+	IDBits            int           // Width of IDs. node.DEFAULT_ID_BITS if 0; small widths make fingers easier to read.
+	ReadRepair        bool          // Reads consult every replica and repair the stale ones, see node.Node.ReadRepair.
+}
+
+/*
+A running simulation. It is not safe for concurrent use.
+*/
+type Sim struct {
+	Seed    int64
+	Clock   *Clock
+	Network *Network
+	Nodes   []*node.Node // Every node ever added, by index. Only the alive ones take part in the ring.
+	Steps   int          // Steps run so far.
+
+	rand              *rand.Rand
+	replicationFactor int
+	succListLength    int
+	rpcTimeout        time.Duration
+	idBits            int
+	readRepair        bool
+	alive             []bool
+	keys              map[ring.ID]message.RRSet // Keys put into the ring, which must stay retrievable.
+	dataDir           string
+}
+
+/*
+Creates a ring of cfg.Nodes nodes. The first one creates the network and the others join it one at a time,
+through a random node of the ring, without running any step in between.
+*/
+func New(cfg Config) (*Sim, error) {
+	if cfg.Nodes < 1 {
+		return nil, fmt.Errorf("a simulation needs at least 1 node, got %d", cfg.Nodes)
+	}
+	dataDir, err := os.MkdirTemp("", "dns-chord-sim")
+	if err != nil {
+		return nil, err
+	}
+	s := &Sim{
+		Seed:              cfg.Seed,
+		Clock:             NewClock(Epoch),
+		rand:              rand.New(rand.NewSource(cfg.Seed)),
+		replicationFactor: cfg.ReplicationFactor,
+		succListLength:    cfg.SuccListLength,
+		rpcTimeout:        cfg.RPCTimeout,
+		idBits:            cfg.IDBits,
+		readRepair:        cfg.ReadRepair,
+		keys:              make(map[ring.ID]message.RRSet),
+		dataDir:           dataDir,
+	}
+	if s.rpcTimeout <= 0 {
+		s.rpcTimeout = node.DEFAULT_RPC_TIMEOUT
+	}
+	if s.replicationFactor == 0 {
+		s.replicationFactor = node.DEFAULT_REPLICATION_FACTOR
+	}
+	if s.succListLength == 0 {
+		s.succListLength = node.DEFAULT_SUCC_LIST_LENGTH
+	}
+	if s.idBits == 0 {
+		s.idBits = node.DEFAULT_ID_BITS
+	}
+	if err := ring.CheckBits(s.idBits); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+	s.Network = NewNetwork(s.Clock, s.rand, s.rpcTimeout)
+	for i := 0; i < cfg.Nodes; i++ {
+		if _, err := s.AddNode(); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+/*
+Removes the data directory of the nodes.
+*/
+func (s *Sim) Close() error {
+	return os.RemoveAll(s.dataDir)
+}
+
+/*
+Adds a node with a random ID, which creates the network if no node is alive, or joins it through a random
+alive node. Returns the index of the node in Nodes.
+*/
+func (s *Sim) AddNode() (int, error) {
+	i := len(s.Nodes)
+	addr := net.JoinHostPort(fmt.Sprintf("10.0.%d.%d", i/256, i%256), "3000")
+	n := &node.Node{
+		Nodeid:            s.newID(),
+		IP:                addr,
+		CachedQuery:       cache.NewLRU[ring.ID, node.CacheEntry](node.DEFAULT_CACHE_SIZE),
+		HashIPStorage:     make(map[ring.ID]map[ring.ID]message.RRSet),
+		DataDir:           s.dataDir,
+		ReplicationFactor: s.replicationFactor,
+		SuccListLength:    s.succListLength,
+		RPCTimeout:        s.rpcTimeout,
+		IDBits:            s.idBits,
+		ReadRepair:        s.readRepair,
+		Transport:         s.Network.Transport(addr),
+		Now:               s.Clock.Now,
+		Manual:            true,
+	}
+	if err := n.Listen(addr); err != nil {
+		return -1, err
+	}
+	alive := s.Alive()
+	s.Nodes = append(s.Nodes, n)
+	s.alive = append(s.alive, true)
+	if len(alive) == 0 {
+		n.CreateNetwork()
+		return i, nil
+	}
+	helper := s.Nodes[alive[s.rand.Intn(len(alive))]]
+	if err := n.JoinNetwork(helper.IP); err != nil {
+		s.Kill(i)
+		return i, fmt.Errorf("seed %d: node %d could not join through %s: %w", s.Seed, i, helper.IP, err)
+	}
+	return i, nil
+}
+
+func (s *Sim) newID() ring.ID {
+	for {
+		var id ring.ID
+		s.rand.Read(id[:])
+		id = id.Mod(s.idBits)
+		unique := true
+		for _, n := range s.Nodes {
+			unique = unique && n.Nodeid != id
+		}
+		if unique {
+			return id
+		}
+	}
+}
+
+/*
+Crashes node i: it stops answering messages and running its tasks, without telling anyone.
+*/
+func (s *Sim) Kill(i int) {
+	s.alive[i] = false
+	s.Network.Down(s.Nodes[i].IP)
+}
+
+/*
+Makes node i leave the network gracefully.
+*/
+func (s *Sim) Leave(i int) error {
+	s.alive[i] = false
+	return s.Nodes[i].Leave()
+}
+
+/*
+Returns the indexes of the alive nodes.
+*/
+func (s *Sim) Alive() []int {
+	var alive []int
+	for i, ok := range s.alive {
+		if ok {
+			alive = append(alive, i)
+		}
+	}
+	return alive
+}
+
+/*
+Splits the alive nodes into groups that cannot reach each other. Nodes that are in no group can reach every
+node.
+*/
+func (s *Sim) Partition(groups ...[]int) {
+	for g, group := range groups {
+		for _, other := range groups[g+1:] {
+			for _, i := range group {
+				for _, j := range other {
+					s.Network.SetFault(s.Nodes[i].IP, s.Nodes[j].IP, Fault{Blocked: true})
+					s.Network.SetFault(s.Nodes[j].IP, s.Nodes[i].IP, Fault{Blocked: true})
+				}
+			}
+		}
+	}
+}
+
+/*
+Sets the faults on the messages sent from node i to node j.
+*/
+func (s *Sim) SetFault(i, j int, fault Fault) {
+	s.Network.SetFault(s.Nodes[i].IP, s.Nodes[j].IP, fault)
+}
+
+// Removes every partition and fault.
+func (s *Sim) Heal() {
+	s.Network.Heal()
+}
+
+/*
+Advances the clock by a second, and runs the periodic tasks of every alive node once, in a random order.
+*/
+func (s *Sim) Step() {
+	s.Steps++
+	s.Clock.Advance(time.Second)
+	alive := s.Alive()
+	for _, k := range s.rand.Perm(len(alive)) {
+		s.Nodes[alive[k]].Tick()
+	}
+}
+
+func (s *Sim) Run(steps int) {
+	for i := 0; i < steps; i++ {
+		s.Step()
+	}
+}
+
+/*
+Runs steps until the invariants hold, for at most maxSteps steps. Returns the violations of the last Check if
+they still do not hold.
+*/
+func (s *Sim) Settle(maxSteps int) error {
+	err := s.Check()
+	for i := 0; i < maxSteps && err != nil; i++ {
+		s.Step()
+		err = s.Check()
+	}
+	return err
+}
+
+/*
+Stores an A record for name in the ring, through a random alive node, the way QueryDNS stores the answers of
+legacy DNS. The record does not expire during the simulation.
+*/
+func (s *Sim) Put(name string) error {
+	// Otherwise puts through different nodes in the same step would be concurrent writes, of which either may win
+	s.Clock.Advance(time.Millisecond)
+	alive := s.Alive()
+	from := s.Nodes[alive[s.rand.Intn(len(alive))]]
+	ip := net.IPv4(10, byte(s.rand.Intn(256)), byte(s.rand.Intn(256)), byte(s.rand.Intn(256))).String()
+	rrset := message.NewRRSet(name, dns.TypeA, []dns.RR{{Name: dns.Fqdn(name), Type: dns.TypeA, Class: dns.ClassINET, TTL: 1 << 30, Data: ip}}, 0, s.Clock.Now())
+	key := ring.Hash(message.RRSetKey(name, dns.TypeA), s.idBits)
+	owner, _ := from.FindSuccessor(key, 0)
+	if err := from.QuorumPut(owner, key, rrset); err != nil {
+		return fmt.Errorf("%s did not store %s: %w", owner.IP, name, err)
+	}
+	s.keys[key] = rrset
+	return nil
+}
+
+/*
+Puts a new version of a random key already put, with another address, and returns its name. The new version
+must replace the previous one on every replica.
+*/
+func (s *Sim) Rewrite() (string, error) {
+	if len(s.keys) == 0 {
+		return "", nil
+	}
+	names := make([]string, 0, len(s.keys))
+	for _, rrset := range s.keys {
+		names = append(names, rrset.Name)
+	}
+	// Sorted, so that the same key is drawn on every run
+	sort.Strings(names)
+	name := names[s.rand.Intn(len(names))]
+	return name, s.Put(name)
+}
+
+/*
+Puts n keys named after the number of keys already put.
+*/
+func (s *Sim) PutKeys(n int) error {
+	for i := 0; i < n; i++ {
+		if err := s.Put(fmt.Sprintf("host%d.example.com", len(s.keys))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Looks up a key through a random alive node, the way QueryDNS does when the key is not cached.
+Returns nil if none of the replicas consulted has it.
+*/
+func (s *Sim) Get(key ring.ID) (*message.RRSet, error) {
+	alive := s.Alive()
+	from := s.Nodes[alive[s.rand.Intn(len(alive))]]
+	owner, _ := from.FindSuccessor(key, 0)
+	return from.QuorumGet(owner, key)
+}
+
+/*
+Returns the alive nodes sorted by ID, which is the order of a correct ring.
+*/
+func (s *Sim) members() []*node.Node {
+	var members []*node.Node
+	for _, i := range s.Alive() {
+		members = append(members, s.Nodes[i])
+	}
+	sort.Slice(members, func(a, b int) bool { return members[a].Nodeid.Cmp(members[b].Nodeid) < 0 })
+	return members
+}
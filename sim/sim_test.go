@@ -0,0 +1,130 @@
+package sim_test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/sim"
+	"github.com/rs/zerolog"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// Seeds each scenario runs with, fewer with -short. A failure names its seed, which replays it with cmd/sim.
+func seeds() []int64 {
+	if testing.Short() {
+		return []int64{1}
+	}
+	return []int64{1, 2, 3}
+}
+
+/*
+Builds a settled ring of 8 nodes holding keys, applies fault to it, heals the network and checks that the
+ring settles again, with every key retrievable from any node.
+*/
+func runRing(t *testing.T, fault func(t *testing.T, s *sim.Sim, r *rand.Rand)) {
+	for _, seed := range seeds() {
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			s, err := sim.New(sim.Config{Nodes: 8, Seed: seed})
+			if err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+			defer s.Close()
+			if err := s.Settle(120); err != nil {
+				t.Fatalf("initial ring: %v", err)
+			}
+			if err := s.PutKeys(50); err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+			// Keys are replicated by a periodic task, and are lost if their owner crashes before it runs
+			s.Run(5)
+
+			fault(t, s, rand.New(rand.NewSource(seed)))
+			s.Heal()
+			if err := s.Settle(120); err != nil {
+				t.Fatalf("after the fault: %v", err)
+			}
+			s.Run(5)
+			if err := s.Check(); err != nil {
+				t.Fatalf("once settled: %v", err)
+			}
+		})
+	}
+}
+
+func pick(s *sim.Sim, r *rand.Rand) int {
+	alive := s.Alive()
+	return alive[r.Intn(len(alive))]
+}
+
+func TestRingCrash(t *testing.T) {
+	runRing(t, func(t *testing.T, s *sim.Sim, r *rand.Rand) {
+		s.Kill(pick(s, r))
+	})
+}
+
+func TestRingLeave(t *testing.T) {
+	runRing(t, func(t *testing.T, s *sim.Sim, r *rand.Rand) {
+		i := pick(s, r)
+		if err := s.Leave(i); err != nil {
+			t.Fatalf("seed %d: node %d could not leave: %v", s.Seed, i, err)
+		}
+	})
+}
+
+func TestRingJoin(t *testing.T) {
+	runRing(t, func(t *testing.T, s *sim.Sim, r *rand.Rand) {
+		for k := 0; k < 2; k++ {
+			if i, err := s.AddNode(); err != nil {
+				t.Fatalf("seed %d: node %d could not join: %v", s.Seed, i, err)
+			}
+		}
+	})
+}
+
+func TestRingPartition(t *testing.T) {
+	runRing(t, func(t *testing.T, s *sim.Sim, r *rand.Rand) {
+		alive := s.Alive()
+		r.Shuffle(len(alive), func(a, b int) { alive[a], alive[b] = alive[b], alive[a] })
+		s.Partition(alive[:len(alive)/2], alive[len(alive)/2:])
+		s.Run(10)
+	})
+}
+
+func TestRingDelay(t *testing.T) {
+	runRing(t, func(t *testing.T, s *sim.Sim, r *rand.Rand) {
+		i, j := pick(s, r), pick(s, r)
+		// Up to past the RPC timeout
+		s.SetFault(i, j, sim.Fault{Delay: time.Duration(r.Intn(4000)) * time.Millisecond})
+		s.SetFault(j, i, sim.Fault{Delay: time.Duration(r.Intn(4000)) * time.Millisecond})
+		s.Run(10)
+	})
+}
+
+func TestRingLoss(t *testing.T) {
+	runRing(t, func(t *testing.T, s *sim.Sim, r *rand.Rand) {
+		for _, i := range s.Alive() {
+			for _, j := range s.Alive() {
+				s.SetFault(i, j, sim.Fault{DropRate: 0.1})
+			}
+		}
+		s.Run(10)
+	})
+}
+
+func TestRingRewrite(t *testing.T) {
+	runRing(t, func(t *testing.T, s *sim.Sim, r *rand.Rand) {
+		for k := 0; k < 5; k++ {
+			if name, err := s.Rewrite(); err != nil {
+				t.Fatalf("seed %d: could not rewrite %s: %v", s.Seed, name, err)
+			}
+		}
+		s.Run(10)
+	})
+}
@@ -0,0 +1,239 @@
+/*
+Stress test for the synchronization of nodes. It runs a ring of nodes in one process and hammers it with
+joins, a leave, queries and menu-style reads while the periodic tasks run, which is meant to be done under the
+race detector. From a test:
+
+	network := transport.NewMemoryNetwork()
+	result, err := stress.Run(stress.Config{
+		Nodes:     8,
+		Duration:  10 * time.Second,
+		Upstream:  "127.0.0.1:17000",
+		Transport: func(int) transport.Transport { return network.Transport() },
+	})
+
+cmd/stress runs it from the command line, over gRPC, net/rpc or the in-memory transport.
+*/
+package stress
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/cache"
+	"github.com/fauzxan/dns-chord/v2/dns"
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/node"
+	"github.com/fauzxan/dns-chord/v2/ring"
+	"github.com/fauzxan/dns-chord/v2/transport"
+)
+
+type Config struct {
+	Nodes      int                             // Nodes in the ring, at least 1. The first one creates it and the others join while queries run.
+	VNodes     int                             // Virtual nodes hosted by each node, 1 if 0.
+	Duration   time.Duration                   // How long queries run. The last node leaves halfway through.
+	Workers    int                             // Goroutines sending queries, 8 if 0.
+	Names      int                             // Distinct websites queried, 200 if 0.
+	ReadRepair bool                            // Reads consult every replica and repair the stale ones, see node.Node.ReadRepair.
+	Upstream   string                          // Address the stub upstream DNS server listens on, which answers every name.
+	BasePort   int                             // Node i listens on 127.0.0.1:BasePort+1+i. The port of Upstream if 0.
+	Transport  func(i int) transport.Transport // Transport of node i.
+}
+
+/*
+Outcome of a run: the number of queries, and the counters of the nodes summed over the ring.
+*/
+type Result struct {
+	Nodes        int
+	Queries      int64
+	Failures     int64 // Queries that returned an error.
+	Sync         node.SyncStats
+	Repairs      node.RepairStats
+	Hints        node.HintStats
+	PendingHints int
+}
+
+/*
+Upstream DNS server answering every name with an A record, so that the stress test does not depend on the
+network. The short TTL makes records expire while the test runs.
+*/
+type stubResolver struct{}
+
+func (stubResolver) Resolve(name string, rrtype uint16) ([]dns.RR, []dns.RR, error) {
+	if rrtype != dns.TypeA {
+		return nil, nil, nil
+	}
+	hash := ring.Hash(name, ring.MAX_BITS)
+	ip := net.IPv4(10, hash[0], hash[1], hash[2]).String()
+	return []dns.RR{{Name: dns.Fqdn(name), Type: dns.TypeA, Class: dns.ClassINET, TTL: 3, Data: ip}}, nil, nil
+}
+
+/*
+Runs the stress test, and returns its result. The error joins the nodes that could not join or leave, which
+does not stop the run; setup errors are returned without a result.
+*/
+func Run(cfg Config) (Result, error) {
+	if cfg.Nodes < 1 {
+		return Result{}, fmt.Errorf("a ring needs at least 1 node, got %d", cfg.Nodes)
+	}
+	cfg.VNodes = max(cfg.VNodes, 1)
+	if cfg.Workers <= 0 {
+		cfg.Workers = 8
+	}
+	if cfg.Names <= 0 {
+		cfg.Names = 200
+	}
+	if cfg.BasePort == 0 {
+		_, port, err := net.SplitHostPort(cfg.Upstream)
+		if err != nil {
+			return Result{}, err
+		}
+		if cfg.BasePort, err = strconv.Atoi(port); err != nil {
+			return Result{}, err
+		}
+	}
+
+	dataDir, err := os.MkdirTemp("", "dns-chord-stress")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(dataDir)
+
+	upstream := dns.Server{Addr: cfg.Upstream, Resolver: stubResolver{}}
+	if err := upstream.ListenAndServe(); err != nil {
+		return Result{}, err
+	}
+
+	members := make([]*node.Node, cfg.Nodes)
+	hosts := make([]*node.Host, cfg.Nodes)
+	for i := range members {
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(cfg.BasePort+1+i))
+		members[i] = &node.Node{
+			Nodeid:            ring.Hash(addr, node.DEFAULT_ID_BITS),
+			IP:                addr,
+			CachedQuery:       cache.NewLRU[ring.ID, node.CacheEntry](32), // Small, so that entries get evicted
+			HashIPStorage:     make(map[ring.ID]map[ring.ID]message.RRSet),
+			Upstream:          dns.NewUpstream([]string{cfg.Upstream}, 500*time.Millisecond),
+			DataDir:           dataDir,
+			ReplicationFactor: 2,
+			ReadRepair:        cfg.ReadRepair,
+			Transport:         cfg.Transport(i),
+		}
+		hosts[i] = node.NewHost(members[i], cfg.VNodes)
+		if err := hosts[i].Listen(addr); err != nil {
+			return Result{}, err
+		}
+	}
+	if err := hosts[0].CreateNetwork(); err != nil {
+		return Result{}, err
+	}
+
+	// Nodes that are part of the ring and can be queried
+	var joined atomic.Int32
+	joined.Store(1)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	var errsMu sync.Mutex
+	var errs []error
+	fail := func(err error) {
+		errsMu.Lock()
+		errs = append(errs, err)
+		errsMu.Unlock()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i < len(members); i++ {
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Millisecond)
+			if err := hosts[i].JoinNetwork(members[rand.Intn(i)].IP); err != nil {
+				fail(fmt.Errorf("node %d could not join: %w", i, err))
+			}
+			joined.Add(1)
+		}
+	}()
+
+	var queries, failures atomic.Int64
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// The last node leaves halfway through, and is not queried anymore
+				me := members[rand.Intn(int(joined.Load()))]
+				website := fmt.Sprintf("host%d.example.com", rand.Intn(cfg.Names))
+				if _, err := me.QueryDNS(website, dns.TypeA); err != nil {
+					failures.Add(1)
+				}
+				queries.Add(1)
+			}
+		}()
+	}
+
+	// Reads done by the menu
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			me := members[rand.Intn(int(joined.Load()))]
+			me.PrintFingers()
+			me.PrintSuccessor()
+			me.PrintPredecessor()
+			me.PrintStorage()
+			me.PrintCache()
+		}
+	}()
+
+	time.Sleep(cfg.Duration / 2)
+	if last := hosts[len(hosts)-1]; len(members) > 1 && int(joined.Load()) == len(members) {
+		joined.Add(-1)
+		if err := last.Leave(); err != nil {
+			fail(fmt.Errorf("node %d could not leave: %w", len(members)-1, err))
+		}
+	}
+	time.Sleep(cfg.Duration / 2)
+	close(done)
+	wg.Wait()
+
+	// Stops the periodic tasks, which would otherwise keep running after the test. The nodes leave one after
+	// the other, faster than the ring stabilizes, so their hand-offs may fail
+	for _, host := range hosts[:joined.Load()] {
+		host.Leave()
+	}
+
+	result := Result{Nodes: len(members), Queries: queries.Load(), Failures: failures.Load()}
+	for _, host := range hosts {
+		for _, n := range host.Nodes {
+			stats := n.SyncStats()
+			result.Sync.Rounds += stats.Rounds
+			result.Sync.InSync += stats.InSync
+			result.Sync.BytesSent += stats.BytesSent
+			result.Sync.BytesFull += stats.BytesFull
+			repaired := n.RepairStats()
+			result.Repairs.Divergent += repaired.Divergent
+			result.Repairs.Repairs += repaired.Repairs
+			hinted := n.HintStats()
+			result.Hints.Stored += hinted.Stored
+			result.Hints.Delivered += hinted.Delivered
+			result.Hints.Expired += hinted.Expired
+			result.PendingHints += n.PendingHints()
+		}
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,219 @@
+package transport
+
+import (
+	"github.com/fauzxan/dns-chord/v2/dns"
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/message/chordpb"
+	"github.com/fauzxan/dns-chord/v2/ring"
+)
+
+/*
+Conversions between the messages handled by nodes and the ones of chordpb. A node that is not known has the
+zero ID and no address, and is sent as an unset chordpb.Node.
+*/
+
+func toNode(id ring.ID, addr string) *chordpb.Node {
+	if id.IsZero() && addr == "" {
+		return nil
+	}
+	return &chordpb.Node{Id: id.Bytes(), Addr: addr}
+}
+
+func toNodes(pointers []message.Pointer) []*chordpb.Node {
+	out := make([]*chordpb.Node, 0, len(pointers))
+	for _, pointer := range pointers {
+		if node := toNode(pointer.Nodeid, pointer.IP); node != nil {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+func toRecords(rrs []dns.RR) []*chordpb.Record {
+	if rrs == nil {
+		return nil
+	}
+	out := make([]*chordpb.Record, len(rrs))
+	for i, rr := range rrs {
+		out[i] = &chordpb.Record{Name: rr.Name, Type: uint32(rr.Type), Class: uint32(rr.Class), Ttl: rr.TTL, Data: rr.Data}
+	}
+	return out
+}
+
+func fromRecords(records []*chordpb.Record) []dns.RR {
+	if records == nil {
+		return nil
+	}
+	out := make([]dns.RR, len(records))
+	for i, r := range records {
+		out[i] = dns.RR{Name: r.GetName(), Type: uint16(r.GetType()), Class: uint16(r.GetClass()), TTL: r.GetTtl(), Data: r.GetData()}
+	}
+	return out
+}
+
+func toRRSet(rrset *message.RRSet) *chordpb.RRSet {
+	if rrset == nil {
+		return nil
+	}
+	return &chordpb.RRSet{
+		Name:      rrset.Name,
+		Type:      uint32(rrset.Type),
+		Records:   toRecords(rrset.Records),
+		Expires:   rrset.Expires,
+		Rcode:     int32(rrset.Rcode),
+		Authority: toRecords(rrset.Authority),
+		Version:   toVersion(rrset.Version),
+	}
+}
+
+func toEntries(rrsets map[ring.ID]message.RRSet) []*chordpb.Entry {
+	if rrsets == nil {
+		return nil
+	}
+	out := make([]*chordpb.Entry, 0, len(rrsets))
+	for key, rrset := range rrsets {
+		out = append(out, &chordpb.Entry{Key: key.Bytes(), Rrset: toRRSet(&rrset)})
+	}
+	return out
+}
+
+func toReplicas(replicas map[ring.ID]map[ring.ID]message.RRSet) []*chordpb.Storage {
+	if replicas == nil {
+		return nil
+	}
+	out := make([]*chordpb.Storage, 0, len(replicas))
+	for id, rrsets := range replicas {
+		out = append(out, &chordpb.Storage{OwnerId: id.Bytes(), Entries: toEntries(rrsets)})
+	}
+	return out
+}
+
+// Reply of a message that succeeds if ok is true.
+func ack(ok bool) message.ResponseMessage {
+	if ok {
+		return message.ResponseMessage{Type: message.ACK}
+	}
+	return message.ResponseMessage{}
+}
+
+/*
+Decodes the IDs of a chordpb message, which may be invalid. Only the first error is kept, so that a message
+can be converted in one go and checked once.
+*/
+type decoder struct {
+	err error
+}
+
+func (d *decoder) id(b []byte) ring.ID {
+	id, err := ring.FromBytes(b)
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+	return id
+}
+
+func (d *decoder) nodes(nodes []*chordpb.Node) []message.Pointer {
+	if nodes == nil {
+		return nil
+	}
+	out := make([]message.Pointer, len(nodes))
+	for i, node := range nodes {
+		out[i] = message.Pointer{Nodeid: d.id(node.GetId()), IP: node.GetAddr()}
+	}
+	return out
+}
+
+func toDrops(drop map[ring.ID]message.Version) []*chordpb.Drop {
+	if drop == nil {
+		return nil
+	}
+	out := make([]*chordpb.Drop, 0, len(drop))
+	for key, version := range drop {
+		out = append(out, &chordpb.Drop{Key: key.Bytes(), Version: toVersion(version)})
+	}
+	return out
+}
+
+func toVersion(version message.Version) *chordpb.Version {
+	return &chordpb.Version{Wall: version.Wall, Logical: version.Logical, Origin: version.Origin.Bytes()}
+}
+
+func toSubtrees(subtrees []int) []uint32 {
+	if subtrees == nil {
+		return nil
+	}
+	out := make([]uint32, len(subtrees))
+	for i, subtree := range subtrees {
+		out[i] = uint32(subtree)
+	}
+	return out
+}
+
+func fromSubtrees(subtrees []uint32) []int {
+	if subtrees == nil {
+		return nil
+	}
+	out := make([]int, len(subtrees))
+	for i, subtree := range subtrees {
+		out[i] = int(subtree)
+	}
+	return out
+}
+
+func (d *decoder) version(version *chordpb.Version) message.Version {
+	return message.Version{Wall: version.GetWall(), Logical: version.GetLogical(), Origin: d.id(version.GetOrigin())}
+}
+
+func (d *decoder) drops(drops []*chordpb.Drop) map[ring.ID]message.Version {
+	if drops == nil {
+		return nil
+	}
+	out := make(map[ring.ID]message.Version, len(drops))
+	for _, drop := range drops {
+		out[d.id(drop.GetKey())] = d.version(drop.GetVersion())
+	}
+	return out
+}
+
+func (d *decoder) rrset(rrset *chordpb.RRSet) *message.RRSet {
+	if rrset == nil {
+		return nil
+	}
+	return &message.RRSet{
+		Name:      rrset.GetName(),
+		Type:      uint16(rrset.GetType()),
+		Records:   fromRecords(rrset.GetRecords()),
+		Expires:   rrset.GetExpires(),
+		Rcode:     int(rrset.GetRcode()),
+		Authority: fromRecords(rrset.GetAuthority()),
+		Version:   d.version(rrset.GetVersion()),
+	}
+}
+
+func (d *decoder) entries(entries []*chordpb.Entry) map[ring.ID]message.RRSet {
+	if entries == nil {
+		return nil
+	}
+	out := make(map[ring.ID]message.RRSet, len(entries))
+	for _, entry := range entries {
+		if rrset := d.rrset(entry.GetRrset()); rrset != nil {
+			out[d.id(entry.GetKey())] = *rrset
+		}
+	}
+	return out
+}
+
+func (d *decoder) replicas(replicas []*chordpb.Storage) map[ring.ID]map[ring.ID]message.RRSet {
+	if replicas == nil {
+		return nil
+	}
+	out := make(map[ring.ID]map[ring.ID]message.RRSet, len(replicas))
+	for _, storage := range replicas {
+		rrsets := d.entries(storage.GetEntries())
+		if rrsets == nil {
+			rrsets = make(map[ring.ID]message.RRSet)
+		}
+		out[d.id(storage.GetOwnerId())] = rrsets
+	}
+	return out
+}
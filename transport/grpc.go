@@ -0,0 +1,501 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+	"github.com/fauzxan/dns-chord/v2/message/chordpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Version of the protocol spoken over gRPC, reported by Ping. See chord.proto.
+const protocolVersion = 2
+
+/*
+How long a peer has to answer the HTTP/2 handshake. Peers that only speak net/rpc never answer it, and calls
+to them fall back to net/rpc once it times out.
+*/
+const handshakeTimeout = 500 * time.Millisecond
+
+// Metadata key carrying RequestMessage.Receiver, which is not part of the RPCs of chordpb.
+const receiverKey = "chord-receiver"
+
+// First bytes sent by HTTP/2 clients, which tell gRPC connections apart from net/rpc ones.
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+/*
+Transport over gRPC, with one typed RPC per message type (see chordpb). To keep mixed-version rings working
+during upgrades, it also speaks the net/rpc protocol of TCP:
+  - Listen serves both protocols on the same port, telling them apart by the first bytes of each connection.
+  - Call falls back to net/rpc when a peer cannot be reached over gRPC but answers over net/rpc, and keeps
+    using net/rpc with that peer for a while before trying gRPC again.
+*/
+type GRPC struct {
+	idle   time.Duration
+	legacy *TCP // Calls to peers that only speak net/rpc.
+
+	mu        sync.Mutex
+	conns     map[string]*grpc.ClientConn
+	legacyTTL map[string]time.Time // Peers known to only speak net/rpc, until when.
+	servers   []*grpc.Server
+	listeners []net.Listener
+	done      chan struct{} // Closed by Close.
+}
+
+/*
+Creates a gRPC transport that closes connections unused for longer than idle.
+*/
+func NewGRPC(idle time.Duration) *GRPC {
+	return &GRPC{
+		idle:      idle,
+		legacy:    NewTCP(idle),
+		conns:     make(map[string]*grpc.ClientConn),
+		legacyTTL: make(map[string]time.Time),
+		done:      make(chan struct{}),
+	}
+}
+
+func (t *GRPC) Call(ctx context.Context, addr string, msg message.RequestMessage) (message.ResponseMessage, error) {
+	if t.isLegacy(addr) {
+		return t.legacy.Call(ctx, addr, msg)
+	}
+	conn, err := t.conn(addr)
+	if err != nil {
+		return message.ResponseMessage{}, err
+	}
+	reply, err := invoke(ctx, chordpb.NewChordClient(conn), msg)
+	if err == nil {
+		return reply, nil
+	}
+	if ctx.Err() != nil {
+		return message.ResponseMessage{}, unreachable(addr, ctx.Err())
+	}
+	st, ok := status.FromError(err)
+	switch {
+	case !ok:
+		return message.ResponseMessage{}, err
+	case st.Code() == codes.Unavailable:
+		// The peer is down, or predates gRPC and did not answer the handshake
+		reply, legacyErr := t.legacy.Call(ctx, addr, msg)
+		if errors.Is(legacyErr, ErrPeerUnreachable) {
+			return message.ResponseMessage{}, unreachable(addr, err)
+		}
+		t.setLegacy(addr)
+		return reply, legacyErr
+	case st.Code() == codes.DeadlineExceeded || st.Code() == codes.Canceled:
+		return message.ResponseMessage{}, unreachable(addr, err)
+	default:
+		// Including Unimplemented, from peers that do not know the RPC
+		return message.ResponseMessage{}, &RemoteError{Peer: addr, Message: st.Message()}
+	}
+}
+
+/*
+Returns the client connection to addr, creating it if needed. gRPC connects lazily, and closes the underlying
+connection when it is idle.
+*/
+func (t *GRPC) conn(addr string) (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return nil, unreachable(addr, ErrClosed)
+	default:
+	}
+	if conn, ok := t.conns[addr]; ok {
+		return conn, nil
+	}
+	if addr == "" {
+		return nil, unreachable(addr, errors.New("missing address"))
+	}
+	// passthrough leaves name resolution to the dialer, like net.Dial
+	conn, err := grpc.NewClient("passthrough:///"+addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithIdleTimeout(t.idle),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.Config{BaseDelay: handshakeTimeout, Multiplier: 1.6, Jitter: 0.2, MaxDelay: 10 * time.Second},
+			MinConnectTimeout: handshakeTimeout,
+		}),
+	)
+	if err != nil {
+		return nil, unreachable(addr, err)
+	}
+	t.conns[addr] = conn
+	return conn, nil
+}
+
+func (t *GRPC) isLegacy(addr string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.legacyTTL[addr]
+	if ok && time.Now().After(until) {
+		// Try gRPC again, the peer may have been upgraded
+		delete(t.legacyTTL, addr)
+		return false
+	}
+	return ok
+}
+
+func (t *GRPC) setLegacy(addr string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.legacyTTL[addr] = time.Now().Add(t.idle)
+}
+
+/*
+Binds addr and serves both gRPC and net/rpc connections in the background.
+*/
+func (t *GRPC) Listen(addr string, handler Handler) error {
+	legacy, err := newRPCServer(handler)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	chordpb.RegisterChordServer(server, &chordServer{handler: handler})
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		listener.Close()
+		return ErrClosed
+	default:
+	}
+	t.listeners = append(t.listeners, listener)
+	t.servers = append(t.servers, server)
+
+	grpcConns := newConnListener(listener.Addr())
+	go server.Serve(grpcConns)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				grpcConns.Close()
+				return
+			}
+			go func() {
+				// Both kinds of clients speak first, so this does not wait on the server
+				r := bufio.NewReader(conn)
+				preface, err := r.Peek(len(http2Preface))
+				if err != nil && len(preface) == 0 {
+					conn.Close()
+					return
+				}
+				conn = &peekedConn{Conn: conn, r: r}
+				if bytes.Equal(preface, http2Preface) {
+					grpcConns.deliver(conn)
+				} else {
+					legacy.ServeConn(conn)
+				}
+			}()
+		}
+	}()
+	return nil
+}
+
+func (t *GRPC) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return nil
+	default:
+	}
+	close(t.done)
+	for _, listener := range t.listeners {
+		listener.Close()
+	}
+	for _, server := range t.servers {
+		server.Stop()
+	}
+	for _, conn := range t.conns {
+		conn.Close()
+	}
+	return t.legacy.Close()
+}
+
+/*
+Sends msg through the RPC of its type, and converts the response back to the reply the handler of the peer
+would have given.
+*/
+func invoke(ctx context.Context, client chordpb.ChordClient, msg message.RequestMessage) (message.ResponseMessage, error) {
+	if msg.Receiver != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, receiverKey, msg.Receiver)
+	}
+	var d decoder
+	var reply message.ResponseMessage
+	var err error
+	switch msg.Type {
+	case message.PING:
+		_, err = client.Ping(ctx, &chordpb.PingRequest{})
+		reply = ack(true)
+	case message.GET_SUCCESSOR:
+		var resp *chordpb.GetSuccessorResponse
+		resp, err = client.GetSuccessor(ctx, &chordpb.GetSuccessorRequest{})
+		reply = message.ResponseMessage{
+			Nodeid:     d.id(resp.GetSuccessor().GetId()),
+			IP:         resp.GetSuccessor().GetAddr(),
+			Successors: d.nodes(resp.GetSuccessors()),
+		}
+	case message.FIND_SUCCESSOR:
+		var resp *chordpb.FindSuccessorResponse
+		resp, err = client.FindSuccessor(ctx, &chordpb.FindSuccessorRequest{Id: msg.TargetId.Bytes(), HopCount: int32(msg.HopCount)})
+		reply = message.ResponseMessage{Type: message.ACK, Nodeid: d.id(resp.GetSuccessor().GetId()), IP: resp.GetSuccessor().GetAddr()}
+	case message.GET_PREDECESSOR:
+		var resp *chordpb.GetPredecessorResponse
+		resp, err = client.GetPredecessor(ctx, &chordpb.GetPredecessorRequest{})
+		reply = message.ResponseMessage{Nodeid: d.id(resp.GetPredecessor().GetId()), IP: resp.GetPredecessor().GetAddr()}
+	case message.NOTIFY:
+		var resp *chordpb.NotifyResponse
+		resp, err = client.Notify(ctx, &chordpb.NotifyRequest{Candidate: toNode(msg.TargetId, msg.IP)})
+		reply = ack(resp.GetAccepted())
+	case message.GET:
+		var resp *chordpb.GetResponse
+		resp, err = client.Get(ctx, &chordpb.GetRequest{Key: msg.TargetId.Bytes(), Owner: msg.Owner.Bytes()})
+		reply = message.ResponseMessage{QueryResponse: d.rrset(resp.GetRrset())}
+	case message.PUT:
+		var resp *chordpb.PutResponse
+		resp, err = client.Put(ctx, &chordpb.PutRequest{OwnerId: msg.TargetId.Bytes(), Entries: toEntries(msg.Payload)})
+		reply = ack(resp.GetStored())
+	case message.SHIFT:
+		var resp *chordpb.ShiftResponse
+		resp, err = client.Shift(ctx, &chordpb.ShiftRequest{Joining: toNode(msg.TargetId, msg.IP)})
+		reply = message.ResponseMessage{Type: message.ACK, Payload: d.entries(resp.GetEntries()), Replicas: d.replicas(resp.GetReplicas())}
+	case message.REPLICATE:
+		_, err = client.Replicate(ctx, &chordpb.ReplicateRequest{OwnerId: msg.TargetId.Bytes(), Entries: toEntries(msg.Payload), Drops: toDrops(msg.Drop)})
+		reply = ack(true)
+	case message.SYNC:
+		var resp *chordpb.SyncResponse
+		resp, err = client.Sync(ctx, &chordpb.SyncRequest{OwnerId: msg.TargetId.Bytes(), Level: uint32(msg.Level), Subtrees: toSubtrees(msg.Subtrees), Hashes: msg.Hashes})
+		reply = message.ResponseMessage{Type: message.ACK, Subtrees: fromSubtrees(resp.GetSubtrees()), Hashes: resp.GetHashes(), Payload: d.entries(resp.GetEntries())}
+	case message.LEAVE:
+		_, err = client.Leave(ctx, &chordpb.LeaveRequest{LeavingId: msg.Sender.Bytes(), Predecessor: toNode(msg.TargetId, msg.IP), Entries: toEntries(msg.Payload)})
+		reply = ack(true)
+	case message.SET_SUCCESSOR:
+		var resp *chordpb.SetSuccessorResponse
+		resp, err = client.SetSuccessor(ctx, &chordpb.SetSuccessorRequest{LeavingId: msg.Sender.Bytes(), Successor: toNode(msg.TargetId, msg.IP)})
+		reply = ack(resp.GetAccepted())
+	case message.FLUSH:
+		_, err = client.Flush(ctx, &chordpb.FlushRequest{OwnerId: msg.Sender.Bytes()})
+		reply = ack(true)
+	default:
+		return message.ResponseMessage{}, fmt.Errorf("no RPC for message type %q", msg.Type)
+	}
+	if err != nil {
+		return message.ResponseMessage{}, err
+	}
+	if d.err != nil {
+		return message.ResponseMessage{}, fmt.Errorf("invalid reply to %s: %w", msg.Type, d.err)
+	}
+	return reply, nil
+}
+
+/*
+Serves the RPCs of chordpb by converting them to the messages of the handler. Errors of the handler are sent
+with their message, and become a *RemoteError for the caller.
+*/
+type chordServer struct {
+	chordpb.UnimplementedChordServer
+	handler Handler
+}
+
+/*
+Passes msg, decoded with d, to the handler, along with the receiver sent in the metadata of ctx. Requests with
+invalid IDs are rejected without reaching it.
+*/
+func (s *chordServer) handle(ctx context.Context, d *decoder, msg message.RequestMessage) (message.ResponseMessage, error) {
+	if d.err != nil {
+		return message.ResponseMessage{}, status.Errorf(codes.InvalidArgument, "invalid %s request: %v", msg.Type, d.err)
+	}
+	if values := metadata.ValueFromIncomingContext(ctx, receiverKey); len(values) > 0 {
+		msg.Receiver = values[0]
+	}
+	var reply message.ResponseMessage
+	err := s.handler.HandleIncomingMessage(&msg, &reply)
+	return reply, err
+}
+
+func (s *chordServer) Ping(ctx context.Context, req *chordpb.PingRequest) (*chordpb.PingResponse, error) {
+	if _, err := s.handle(ctx, &decoder{}, message.RequestMessage{Type: message.PING}); err != nil {
+		return nil, err
+	}
+	return &chordpb.PingResponse{Version: protocolVersion}, nil
+}
+
+func (s *chordServer) GetSuccessor(ctx context.Context, req *chordpb.GetSuccessorRequest) (*chordpb.GetSuccessorResponse, error) {
+	reply, err := s.handle(ctx, &decoder{}, message.RequestMessage{Type: message.GET_SUCCESSOR})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.GetSuccessorResponse{Successor: toNode(reply.Nodeid, reply.IP), Successors: toNodes(reply.Successors)}, nil
+}
+
+func (s *chordServer) FindSuccessor(ctx context.Context, req *chordpb.FindSuccessorRequest) (*chordpb.FindSuccessorResponse, error) {
+	var d decoder
+	reply, err := s.handle(ctx, &d, message.RequestMessage{Type: message.FIND_SUCCESSOR, TargetId: d.id(req.GetId()), HopCount: int(req.GetHopCount())})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.FindSuccessorResponse{Successor: toNode(reply.Nodeid, reply.IP)}, nil
+}
+
+func (s *chordServer) GetPredecessor(ctx context.Context, req *chordpb.GetPredecessorRequest) (*chordpb.GetPredecessorResponse, error) {
+	reply, err := s.handle(ctx, &decoder{}, message.RequestMessage{Type: message.GET_PREDECESSOR})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.GetPredecessorResponse{Predecessor: toNode(reply.Nodeid, reply.IP)}, nil
+}
+
+func (s *chordServer) Notify(ctx context.Context, req *chordpb.NotifyRequest) (*chordpb.NotifyResponse, error) {
+	var d decoder
+	candidate := req.GetCandidate()
+	reply, err := s.handle(ctx, &d, message.RequestMessage{Type: message.NOTIFY, TargetId: d.id(candidate.GetId()), IP: candidate.GetAddr()})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.NotifyResponse{Accepted: reply.Type == message.ACK}, nil
+}
+
+func (s *chordServer) Get(ctx context.Context, req *chordpb.GetRequest) (*chordpb.GetResponse, error) {
+	var d decoder
+	reply, err := s.handle(ctx, &d, message.RequestMessage{Type: message.GET, TargetId: d.id(req.GetKey()), Owner: d.id(req.GetOwner())})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.GetResponse{Rrset: toRRSet(reply.QueryResponse)}, nil
+}
+
+func (s *chordServer) Put(ctx context.Context, req *chordpb.PutRequest) (*chordpb.PutResponse, error) {
+	var d decoder
+	reply, err := s.handle(ctx, &d, message.RequestMessage{Type: message.PUT, TargetId: d.id(req.GetOwnerId()), Payload: d.entries(req.GetEntries())})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.PutResponse{Stored: reply.Type == message.ACK}, nil
+}
+
+func (s *chordServer) Shift(ctx context.Context, req *chordpb.ShiftRequest) (*chordpb.ShiftResponse, error) {
+	var d decoder
+	joining := req.GetJoining()
+	reply, err := s.handle(ctx, &d, message.RequestMessage{Type: message.SHIFT, TargetId: d.id(joining.GetId()), IP: joining.GetAddr()})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.ShiftResponse{Entries: toEntries(reply.Payload), Replicas: toReplicas(reply.Replicas)}, nil
+}
+
+func (s *chordServer) Replicate(ctx context.Context, req *chordpb.ReplicateRequest) (*chordpb.ReplicateResponse, error) {
+	var d decoder
+	msg := message.RequestMessage{Type: message.REPLICATE, TargetId: d.id(req.GetOwnerId()), Payload: d.entries(req.GetEntries()), Drop: d.drops(req.GetDrops())}
+	if _, err := s.handle(ctx, &d, msg); err != nil {
+		return nil, err
+	}
+	return &chordpb.ReplicateResponse{}, nil
+}
+
+func (s *chordServer) Leave(ctx context.Context, req *chordpb.LeaveRequest) (*chordpb.LeaveResponse, error) {
+	var d decoder
+	predecessor := req.GetPredecessor()
+	msg := message.RequestMessage{Type: message.LEAVE, Sender: d.id(req.GetLeavingId()), TargetId: d.id(predecessor.GetId()), IP: predecessor.GetAddr(), Payload: d.entries(req.GetEntries())}
+	if _, err := s.handle(ctx, &d, msg); err != nil {
+		return nil, err
+	}
+	return &chordpb.LeaveResponse{}, nil
+}
+
+func (s *chordServer) SetSuccessor(ctx context.Context, req *chordpb.SetSuccessorRequest) (*chordpb.SetSuccessorResponse, error) {
+	var d decoder
+	successor := req.GetSuccessor()
+	reply, err := s.handle(ctx, &d, message.RequestMessage{Type: message.SET_SUCCESSOR, Sender: d.id(req.GetLeavingId()), TargetId: d.id(successor.GetId()), IP: successor.GetAddr()})
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.SetSuccessorResponse{Accepted: reply.Type == message.ACK}, nil
+}
+
+func (s *chordServer) Flush(ctx context.Context, req *chordpb.FlushRequest) (*chordpb.FlushResponse, error) {
+	var d decoder
+	if _, err := s.handle(ctx, &d, message.RequestMessage{Type: message.FLUSH, Sender: d.id(req.GetOwnerId())}); err != nil {
+		return nil, err
+	}
+	return &chordpb.FlushResponse{}, nil
+}
+
+func (s *chordServer) Sync(ctx context.Context, req *chordpb.SyncRequest) (*chordpb.SyncResponse, error) {
+	var d decoder
+	msg := message.RequestMessage{Type: message.SYNC, TargetId: d.id(req.GetOwnerId()), Level: int(req.GetLevel()), Subtrees: fromSubtrees(req.GetSubtrees()), Hashes: req.GetHashes()}
+	reply, err := s.handle(ctx, &d, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &chordpb.SyncResponse{Subtrees: toSubtrees(reply.Subtrees), Hashes: reply.Hashes, Entries: toEntries(reply.Payload)}, nil
+}
+
+/*
+Connection whose first bytes were read ahead to pick the protocol.
+*/
+type peekedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+/*
+Listener handing the gRPC connections accepted by a shared listener to a grpc.Server.
+*/
+type connListener struct {
+	addr  net.Addr
+	conns chan net.Conn
+	once  sync.Once
+	done  chan struct{}
+}
+
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{addr: addr, conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *connListener) deliver(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,166 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+)
+
+var errNoListener = errors.New("nobody listening")
+
+/*
+In-process network connecting Memory transports, so that a whole ring runs inside one process. Messages are
+delivered over channels, and copied through gob like on the wire, so that nodes never share maps.
+*/
+type MemoryNetwork struct {
+	mu        sync.RWMutex
+	endpoints map[string]*endpoint // By listening address.
+}
+
+type endpoint struct {
+	requests chan memoryCall
+	done     chan struct{} // Closed when the endpoint stops listening.
+}
+
+type memoryCall struct {
+	msg   message.RequestMessage
+	reply chan memoryReply
+}
+
+type memoryReply struct {
+	reply message.ResponseMessage
+	err   error
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{endpoints: make(map[string]*endpoint)}
+}
+
+/*
+Returns a new transport attached to the network. Each node needs its own, as closing it stops every address
+it listens to.
+*/
+func (n *MemoryNetwork) Transport() *Memory {
+	return &Memory{network: n}
+}
+
+/*
+Transport of a node in a MemoryNetwork. Addresses are arbitrary strings.
+*/
+type Memory struct {
+	network *MemoryNetwork
+
+	mu     sync.Mutex
+	addrs  []string
+	closed bool
+}
+
+func (m *Memory) Call(ctx context.Context, addr string, msg message.RequestMessage) (message.ResponseMessage, error) {
+	m.network.mu.RLock()
+	ep, ok := m.network.endpoints[addr]
+	m.network.mu.RUnlock()
+	if !ok {
+		return message.ResponseMessage{}, unreachable(addr, errNoListener)
+	}
+	copied, err := copyMessage(msg)
+	if err != nil {
+		return message.ResponseMessage{}, unreachable(addr, err)
+	}
+	call := memoryCall{msg: copied, reply: make(chan memoryReply, 1)}
+	select {
+	case ep.requests <- call:
+	case <-ep.done:
+		return message.ResponseMessage{}, unreachable(addr, errNoListener)
+	case <-ctx.Done():
+		return message.ResponseMessage{}, unreachable(addr, ctx.Err())
+	}
+	select {
+	case r := <-call.reply:
+		return r.reply, r.err
+	case <-ep.done:
+		return message.ResponseMessage{}, unreachable(addr, errNoListener)
+	case <-ctx.Done():
+		return message.ResponseMessage{}, unreachable(addr, ctx.Err())
+	}
+}
+
+/*
+Serves the messages sent to addr. Each message is handled in its own goroutine, like net/rpc does, since
+handlers may send messages themselves.
+*/
+func (m *Memory) Listen(addr string, handler Handler) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	ep := &endpoint{requests: make(chan memoryCall), done: make(chan struct{})}
+	m.network.mu.Lock()
+	if _, ok := m.network.endpoints[addr]; ok {
+		m.network.mu.Unlock()
+		return fmt.Errorf("listen %s: address already in use", addr)
+	}
+	m.network.endpoints[addr] = ep
+	m.network.mu.Unlock()
+	m.addrs = append(m.addrs, addr)
+
+	go func() {
+		for {
+			select {
+			case call := <-ep.requests:
+				go serveMemoryCall(addr, handler, call)
+			case <-ep.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func serveMemoryCall(addr string, handler Handler, call memoryCall) {
+	var reply message.ResponseMessage
+	if err := handler.HandleIncomingMessage(&call.msg, &reply); err != nil {
+		call.reply <- memoryReply{err: &RemoteError{Peer: addr, Message: err.Error()}}
+		return
+	}
+	copied, err := copyMessage(reply)
+	if err != nil {
+		call.reply <- memoryReply{err: unreachable(addr, err)}
+		return
+	}
+	call.reply <- memoryReply{reply: copied}
+}
+
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	m.network.mu.Lock()
+	for _, addr := range m.addrs {
+		close(m.network.endpoints[addr].done)
+		delete(m.network.endpoints, addr)
+	}
+	m.network.mu.Unlock()
+	return nil
+}
+
+/*
+Returns a deep copy of a message, made by encoding and decoding it with gob like net/rpc does.
+*/
+func copyMessage[T any](msg T) (T, error) {
+	var buf bytes.Buffer
+	var copied T
+	if err := gob.NewEncoder(&buf).Encode(msg); err != nil {
+		return copied, err
+	}
+	err := gob.NewDecoder(&buf).Decode(&copied)
+	return copied, err
+}
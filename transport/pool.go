@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+/*
+Pool of RPC clients, one per peer. An rpc.Client multiplexes concurrent calls over a single connection, so the
+connection to a peer is shared by every message sent to it, until it breaks or stays idle for too long.
+Safe for concurrent use.
+*/
+type connPool struct {
+	mu      sync.Mutex
+	clients map[string]*pooledClient // By peer address.
+	idle    time.Duration            // Connections unused for longer are closed by evictIdle.
+}
+
+type pooledClient struct {
+	client   *rpc.Client
+	lastUsed time.Time
+}
+
+func newConnPool(idle time.Duration) *connPool {
+	return &connPool{clients: make(map[string]*pooledClient), idle: idle}
+}
+
+/*
+Returns the client connected to addr, dialing it if there is none. ctx bounds the dial.
+*/
+func (p *connPool) get(ctx context.Context, addr string) (*rpc.Client, error) {
+	p.mu.Lock()
+	if pooled, ok := p.clients[addr]; ok {
+		pooled.lastUsed = time.Now()
+		p.mu.Unlock()
+		return pooled.client, nil
+	}
+	p.mu.Unlock()
+
+	// Dial without holding the lock, so that an unreachable peer does not block calls to the others.
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	client := rpc.NewClient(conn)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pooled, ok := p.clients[addr]; ok {
+		// Another call dialed the peer in the meantime
+		client.Close()
+		pooled.lastUsed = time.Now()
+		return pooled.client, nil
+	}
+	p.clients[addr] = &pooledClient{client: client, lastUsed: time.Now()}
+	return client, nil
+}
+
+/*
+Closes client and removes it from the pool, if it is still the one pooled for addr. Used when the connection
+broke or a call on it timed out.
+*/
+func (p *connPool) discard(addr string, client *rpc.Client) {
+	p.mu.Lock()
+	if pooled, ok := p.clients[addr]; ok && pooled.client == client {
+		delete(p.clients, addr)
+	}
+	p.mu.Unlock()
+	client.Close()
+}
+
+/*
+Closes the connections that were not used since now - idle. Returns how many were closed.
+*/
+func (p *connPool) evictIdle(now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	evicted := 0
+	for addr, pooled := range p.clients {
+		if now.Sub(pooled.lastUsed) > p.idle {
+			pooled.client.Close()
+			delete(p.clients, addr)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+/*
+Closes every connection.
+*/
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, pooled := range p.clients {
+		pooled.client.Close()
+		delete(p.clients, addr)
+	}
+}
@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+)
+
+// Name under which the handler is served, part of the wire format.
+const rpcMethod = "Node.HandleIncomingMessage"
+
+/*
+Transport over TCP, with messages encoded by net/rpc (gob). Connections to other nodes are pooled and closed
+after being idle for a while.
+*/
+type TCP struct {
+	pool *connPool
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	done      chan struct{} // Closed by Close.
+}
+
+/*
+Creates a TCP transport that closes connections unused for longer than idle.
+*/
+func NewTCP(idle time.Duration) *TCP {
+	t := &TCP{pool: newConnPool(idle), done: make(chan struct{})}
+	go t.evictIdle(idle)
+	return t
+}
+
+func (t *TCP) Call(ctx context.Context, addr string, msg message.RequestMessage) (message.ResponseMessage, error) {
+	reply, err := t.call(ctx, addr, msg)
+	if errors.Is(err, rpc.ErrShutdown) {
+		// The pooled connection was closed before the message was sent, e.g. because the peer restarted.
+		reply, err = t.call(ctx, addr, msg)
+	}
+	if errors.Is(err, rpc.ErrShutdown) {
+		err = unreachable(addr, err)
+	}
+	return reply, err
+}
+
+func (t *TCP) call(ctx context.Context, addr string, msg message.RequestMessage) (message.ResponseMessage, error) {
+	clnt, err := t.pool.get(ctx, addr)
+	if err != nil {
+		return message.ResponseMessage{}, unreachable(addr, err)
+	}
+	// Each call decodes into its own reply, which an abandoned call may still write to.
+	reply := new(message.ResponseMessage)
+	call := clnt.Go(rpcMethod, msg, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+	case <-ctx.Done():
+		// The peer hangs, or the caller gave up. The connection may be stuck, so it is not reused.
+		t.pool.discard(addr, clnt)
+		return message.ResponseMessage{}, unreachable(addr, ctx.Err())
+	}
+	var serverErr rpc.ServerError
+	switch {
+	case errors.As(call.Error, &serverErr):
+		return message.ResponseMessage{}, &RemoteError{Peer: addr, Message: string(serverErr)}
+	case errors.Is(call.Error, rpc.ErrShutdown):
+		t.pool.discard(addr, clnt)
+		return message.ResponseMessage{}, call.Error
+	case call.Error != nil:
+		t.pool.discard(addr, clnt)
+		return message.ResponseMessage{}, unreachable(addr, call.Error)
+	}
+	return *reply, nil
+}
+
+/*
+Binds addr and serves incoming connections in the background.
+*/
+func (t *TCP) Listen(addr string, handler Handler) error {
+	server, err := newRPCServer(handler)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		listener.Close()
+		return ErrClosed
+	default:
+	}
+	t.listeners = append(t.listeners, listener)
+	// Not server.Accept, which logs an error when Close stops the listener
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn)
+		}
+	}()
+	return nil
+}
+
+func (t *TCP) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return nil
+	default:
+	}
+	close(t.done)
+	for _, listener := range t.listeners {
+		listener.Close()
+	}
+	t.pool.close()
+	return nil
+}
+
+func (t *TCP) evictIdle(idle time.Duration) {
+	ticker := time.NewTicker(idle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case now := <-ticker.C:
+			t.pool.evictIdle(now)
+		}
+	}
+}
+
+/*
+Creates a net/rpc server of the messages handled by handler.
+*/
+func newRPCServer(handler Handler) (*rpc.Server, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Node", &rpcHandler{handler}); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+/*
+Exposes only HandleIncomingMessage to net/rpc, which would otherwise complain about every other exported
+method of the handler.
+*/
+type rpcHandler struct {
+	handler Handler
+}
+
+func (h *rpcHandler) HandleIncomingMessage(msg *message.RequestMessage, reply *message.ResponseMessage) error {
+	return h.handler.HandleIncomingMessage(msg, reply)
+}
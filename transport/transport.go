@@ -0,0 +1,59 @@
+/*
+How nodes exchange messages. A Node sends and serves messages through a Transport, so that the same node runs
+over TCP in production and in memory when a whole ring is spun up in one process.
+*/
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fauzxan/dns-chord/v2/message"
+)
+
+var (
+	ErrPeerUnreachable = errors.New("peer unreachable") // The peer could not be reached, or did not answer in time.
+	ErrClosed          = errors.New("transport closed")
+)
+
+/*
+Returned by Transport.Call when the peer handled the message but returned an error, e.g. because it does not
+know the message type.
+*/
+type RemoteError struct {
+	Peer    string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("%s returned an error: %s", e.Peer, e.Message)
+}
+
+/*
+Handles the messages received by a Transport. Implemented by node.Node.
+*/
+type Handler interface {
+	HandleIncomingMessage(msg *message.RequestMessage, reply *message.ResponseMessage) error
+}
+
+/*
+Sends messages to other nodes, and serves the messages they send. Implementations must be safe for concurrent
+use.
+*/
+type Transport interface {
+	/*
+		Sends msg to the node listening at addr and returns its reply. Returns an error wrapping
+		ErrPeerUnreachable if the node could not be reached or ctx is done first, or a *RemoteError if its
+		handler returned an error.
+	*/
+	Call(ctx context.Context, addr string, msg message.RequestMessage) (message.ResponseMessage, error)
+	// Serves the messages sent to addr with handler, until the transport is closed.
+	Listen(addr string, handler Handler) error
+	// Stops listening and releases the connections to other nodes.
+	Close() error
+}
+
+func unreachable(addr string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrPeerUnreachable, addr, err)
+}
@@ -1,13 +1,12 @@
 package utility
 
 import (
-	"crypto/sha256"
-	"encoding/binary"
 	"encoding/csv"
+	"fmt"
 	"log"
-	"math"
 	"net"
 	"os"
+	"strings"
 )
 
 /*
@@ -16,33 +15,69 @@ import (
 ***************************************
 */
 
-const M = 32
-
 /*
-Function to generate the hash of the the input IP address
+Function to work out the address other nodes should use to reach us. An explicit advertise address is used
+as is, and may be a hostname or an IPv6 literal (e.g. "[2001:db8::1]:3000"); without a port, the listening
+port is added. Otherwise the listening host is used when it is a specific address, and an address of one
+of our interfaces when it is a wildcard like ":3000" or "0.0.0.0:3000". This never needs an external network.
 */
-func GenerateHash(input string) uint64 {
-	data := []byte(input)
-	id := sha256.Sum256(data)
-	unmoddedID := float64(binary.BigEndian.Uint64(id[:8]))
-	modValue := float64(math.Pow(2, M))
-	moddedID := math.Mod(unmoddedID, modValue)
-	return uint64(moddedID)
+func AdvertiseAddress(listen, advertise string) (string, error) {
+	listenHost, listenPort, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", fmt.Errorf("invalid listen address %q: %w", listen, err)
+	}
+	if advertise != "" {
+		if _, _, err := net.SplitHostPort(advertise); err == nil {
+			return advertise, nil
+		}
+		return net.JoinHostPort(strings.Trim(advertise, "[]"), listenPort), nil
+	}
+	if ip := net.ParseIP(listenHost); listenHost != "" && (ip == nil || !ip.IsUnspecified()) {
+		return listen, nil
+	}
+	return net.JoinHostPort(GetInterfaceIP().String(), listenPort), nil
 }
 
 /*
-Function to automatically get the outbound IP without user input in .env file
+Function to automatically get the IP address of this machine from its network interfaces, preferring
+global IPv4 addresses, then global IPv6 addresses, then private ones (RFC 1918 and unique local), then
+link local ones, and finally loopback.
 */
-func GetOutboundIP() net.IP {
-	conn, err := net.Dial("udp", "8.8.8.8:80")
+func GetInterfaceIP() net.IP {
+	addrs, err := net.InterfaceAddrs()
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("Could not list interface addresses: %v", err)
+		return net.IPv4(127, 0, 0, 1)
 	}
-	defer conn.Close()
-
-	localAddr := conn.LocalAddr().(*net.UDPAddr)
-
-	return localAddr.IP
+	var best net.IP
+	bestRank := -1
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		rank := 0 // loopback and anything else
+		switch {
+		case ip.IsLoopback():
+			rank = 0
+		case ip.IsLinkLocalUnicast():
+			rank = 1
+		case ip.IsPrivate(): // Global unicast too, but only reachable from the same network
+			rank = 2
+		case ip.IsGlobalUnicast() && ip.To4() == nil:
+			rank = 3
+		case ip.IsGlobalUnicast():
+			rank = 4
+		}
+		if rank > bestRank {
+			best, bestRank = ip, rank
+		}
+	}
+	if best == nil {
+		return net.IPv4(127, 0, 0, 1)
+	}
+	return best
 }
 
 func ReadCSV(filename string) ([]string, error) {